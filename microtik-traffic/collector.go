@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/monasticacademy/maple-network-tools/sink"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector fetches traffic from the router, attributes it to devices, and
+// writes usage rows to a sink
+type collector struct {
+	dial     func() (routerClient, error) // connects to the router
+	sink     sink.Sink                    // where usage rows are written
+	local    prefixList                   // address ranges of local hosts
+	registry string                       // path to the device registry file
+	devices  *registry                    // registry from the most recent tick, used if the file cannot be loaded
+	throttle *throttler                   // nil unless throttling is enabled
+
+	beginSnapshot time.Time // time at which the most recent snapshot was taken
+	lostWindow    bool      // true if the traffic for a window was lost since the most recent usage rows
+}
+
+// start clears the router snapshot table so that we don't get a spike at the start
+func (c *collector) start() error {
+	router, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer router.Close()
+
+	_, err = router.Output("/ip accounting snapshot take")
+	if err != nil {
+		return fmt.Errorf("error taking traffic snapshot: %w", err)
+	}
+	c.beginSnapshot = time.Now()
+	return nil
+}
+
+// tick collects usage since the previous tick and writes it to the sink
+func (c *collector) tick(ctx context.Context) error {
+	usages, err := c.collect()
+	if err != nil {
+		return err
+	}
+
+	var rows []proto.Message
+	for _, usage := range usages {
+		rows = append(rows, usage)
+	}
+	return c.sink.Write(ctx, rows)
+}
+
+// collect fetches usage from the router since the previous snapshot
+func (c *collector) collect() ([]*Usage, error) {
+	// open a connection to the router
+	// do not re-use across ticks because it will time out
+	router, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer router.Close()
+
+	// fetch the DHCP lease table
+	dhcpBuf, err := router.Output("/ip dhcp-server lease print terse")
+	if err != nil {
+		return nil, fmt.Errorf("error running DHCP command: %w", err)
+	}
+	leases := parseLeases(dhcpBuf)
+
+	// fetch the router uptime so that we can tell whether it rebooted during this window
+	var uptime time.Duration
+	uptimeBuf, err := router.Output(":put [/system resource get uptime]")
+	if err == nil {
+		uptime, err = parseRouterDuration(string(uptimeBuf))
+	}
+	if err != nil {
+		log.Println("error getting router uptime, will not detect reboots:", err)
+	}
+
+	// take a snapshot
+	_, err = router.Output("/ip accounting snapshot take")
+	if err != nil {
+		return nil, fmt.Errorf("error taking traffic snapshot: %w", err)
+	}
+
+	// the snapshot covers all traffic since the previous snapshot was taken, even
+	// if some ticks failed in between, so measure the window rather than assuming
+	// it equals the tick interval
+	begin, end := c.beginSnapshot, time.Now()
+	c.beginSnapshot = end
+
+	// if the router rebooted then its accounting table was reset, so the window
+	// really began at boot and any traffic before the reboot is lost
+	var partial bool
+	if boot := end.Add(-uptime); uptime > 0 && boot.After(begin) {
+		log.Printf("router rebooted at %v, traffic since %v is lost", boot, begin)
+		begin = boot
+		partial = true
+	}
+
+	// fetch the traffic table. The snapshot has already been replaced, so if
+	// this fails then the traffic in this window is lost, and the next rows
+	// are flagged as partial so that the gap is visible.
+	trafficBuf, err := router.Output("/ip accounting snapshot print terse")
+	if err != nil {
+		c.lostWindow = true
+		return nil, fmt.Errorf("error printing traffic snapshot, traffic from %v to %v is lost: %w", begin, end, err)
+	}
+	traffic := parseTraffic(trafficBuf)
+	if c.lostWindow {
+		partial = true
+		c.lostWindow = false
+	}
+
+	// calculate usage per hostname
+	leaseByIP := make(map[string]DHCPLease)
+	for _, lease := range leases {
+		leaseByIP[lease.IP] = lease
+	}
+
+	// load the device registry on each tick so that edits to the file take
+	// effect. If the file cannot be loaded then carry on with the devices from
+	// the previous tick rather than losing this window's traffic, and do not
+	// save over the file, which may hold hand edits with a typo.
+	devices, err := loadRegistry(c.registry)
+	loaded := err == nil
+	if !loaded {
+		log.Println("error loading device registry, using the devices from the previous tick:", err)
+		devices = c.devices
+		if devices == nil {
+			devices = newRegistry(c.registry)
+		}
+	}
+	c.devices = devices
+
+	// group usage by device where the MAC is known, otherwise by hostname
+	usageByDevice := make(map[string]*Usage)
+	bytesByIP := make(map[string]int64)
+	for _, row := range traffic {
+		var localAddr netip.Addr
+		if addr, ok := c.local.match(row.From); ok {
+			localAddr = addr
+		} else if addr, ok := c.local.match(row.To); ok {
+			localAddr = addr
+		} else {
+			continue
+		}
+		localIP := localAddr.String()
+
+		lease, ok := leaseByIP[localIP]
+		hostname := lease.Hostname
+		if !ok {
+			hostname = "unknown." + lastPart(localIP)
+		}
+		if hostname == "" {
+			hostname = "unnamed." + lastPart(localIP)
+		}
+
+		key := hostname
+		var device *Device
+		if lease.MAC != "" {
+			device = devices.observe(lease.MAC, lease.Hostname, end)
+			key = device.MAC
+		}
+
+		usage := usageByDevice[key]
+		if usage == nil {
+			usage = &Usage{Host: hostname, MAC: lease.MAC}
+			if device != nil {
+				usage.DeviceID = device.ID
+				usage.Label = device.Label
+			}
+			usageByDevice[key] = usage
+		}
+		usage.Bytes += int64(row.Bytes)
+		usage.Packets += int64(row.Packets)
+		bytesByIP[localIP] += int64(row.Bytes)
+	}
+
+	if loaded {
+		err = devices.save()
+		if err != nil {
+			log.Println("error saving device registry:", err)
+		}
+	}
+
+	// add or remove simple queues for hosts that exceed their limit
+	if c.throttle != nil {
+		for ip, bytes := range bytesByIP {
+			c.throttle.record(end, ip, bytes)
+		}
+
+		err := c.throttle.update(end, router.Output)
+		if err != nil {
+			log.Println("error updating throttles:", err)
+		}
+	}
+
+	// print usage info
+	var usages []*Usage
+	for _, usage := range usageByDevice {
+		usage.Begin = begin.UnixMicro()
+		usage.Duration = end.Sub(begin).Milliseconds()
+		usage.Partial = partial
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Bytes > usages[j].Bytes
+	})
+	for _, usage := range usages {
+		log.Printf("%40s %20s %15s %10d packets\n",
+			usage.Host, usage.Label, humanize.Bytes(uint64(usage.Bytes)), usage.Packets)
+	}
+
+	return usages, nil
+}
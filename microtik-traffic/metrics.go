@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/monasticacademy/maple-network-tools/sink"
+	"google.golang.org/protobuf/proto"
+)
+
+// resourceCommand prints the system resources on one line in key=value form
+// with raw numbers, which is easier to parse than "/system resource print"
+const resourceCommand = `:put ("uptime=" . [/system resource get uptime] . ` +
+	`" cpu-load=" . [/system resource get cpu-load] . ` +
+	`" free-memory=" . [/system resource get free-memory] . ` +
+	`" total-memory=" . [/system resource get total-memory] . ` +
+	`" free-hdd-space=" . [/system resource get free-hdd-space] . ` +
+	`" total-hdd-space=" . [/system resource get total-hdd-space])`
+
+// metricsCollector samples router resources, health sensors, interface counters,
+// and DHCP client status, and writes each to its own sink
+type metricsCollector struct {
+	dial       func() (routerClient, error) // connects to the router
+	interfaces map[string]bool              // interfaces to record, or all if empty
+
+	resourceSink   sink.Sink
+	healthSink     sink.Sink
+	interfaceSink  sink.Sink
+	dhcpClientSink sink.Sink
+}
+
+// tick samples all metrics once. A failure in one command does not prevent the
+// others from being recorded.
+func (m *metricsCollector) tick(ctx context.Context) error {
+	router, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer router.Close()
+
+	now := time.Now().UnixMicro()
+
+	var errs []string
+	sample := func(what, cmd string, parse func([]byte, int64) ([]proto.Message, error), s sink.Sink) {
+		buf, err := router.Output(cmd)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("error fetching %s: %v", what, err))
+			return
+		}
+		rows, err := parse(buf, now)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("error parsing %s: %v", what, err))
+			return
+		}
+		err = s.Write(ctx, rows)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("error writing %s: %v", what, err))
+			return
+		}
+		log.Printf("recorded %d %s rows", len(rows), what)
+	}
+
+	sample("resource", resourceCommand, parseResource, m.resourceSink)
+	sample("health", "/system health print", parseHealth, m.healthSink)
+	sample("interface", "/interface print stats terse", m.parseInterfaces, m.interfaceSink)
+	sample("dhcp client", "/ip dhcp-client print terse", parseDHCPClients, m.dhcpClientSink)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// parseInt parses an integer, ignoring a trailing "%" as in "cpu-load=3%"
+func parseInt(s string) (int64, error) {
+	return strconv.ParseInt(strings.TrimSuffix(s, "%"), 10, 64)
+}
+
+// parseResource parses the output of resourceCommand
+func parseResource(buf []byte, now int64) ([]proto.Message, error) {
+	r := RouterResource{Timestamp: now}
+	var err error
+	for _, tok := range strings.Fields(string(buf)) {
+		if v, ok := hasPrefix(tok, "uptime="); ok {
+			var d time.Duration
+			d, err = parseRouterDuration(v)
+			r.Uptime = int64(d.Seconds())
+		} else if v, ok := hasPrefix(tok, "cpu-load="); ok {
+			r.CPULoad, err = parseInt(v)
+		} else if v, ok := hasPrefix(tok, "free-memory="); ok {
+			r.FreeMemory, err = parseInt(v)
+		} else if v, ok := hasPrefix(tok, "total-memory="); ok {
+			r.TotalMemory, err = parseInt(v)
+		} else if v, ok := hasPrefix(tok, "free-hdd-space="); ok {
+			r.FreeHDDSpace, err = parseInt(v)
+		} else if v, ok := hasPrefix(tok, "total-hdd-space="); ok {
+			r.TotalHDDSpace, err = parseInt(v)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %q: %w", tok, err)
+		}
+	}
+	return []proto.Message{&r}, nil
+}
+
+// splitUnit splits a value like "24.1V" or "45C" into a number and a unit
+func splitUnit(s string) (float64, string, error) {
+	i := len(s)
+	for i > 0 && !(s[i-1] >= '0' && s[i-1] <= '9') {
+		i--
+	}
+	v, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return v, s[i:], nil
+}
+
+// parseHealth parses the output of "/system health print", which on RouterOS 6
+// has one "name: value" line per sensor
+func parseHealth(buf []byte, now int64) ([]proto.Message, error) {
+	var rows []proto.Message
+	for _, line := range strings.Split(string(buf), "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		value, unit, err := splitUnit(strings.TrimSpace(parts[1]))
+		if err != nil {
+			// some entries such as "state: ok" are not numeric
+			continue
+		}
+		rows = append(rows, &RouterHealth{
+			Timestamp: now,
+			Name:      name,
+			Value:     value,
+			Unit:      unit,
+		})
+	}
+	return rows, nil
+}
+
+// parseInterfaces parses the output of "/interface print stats terse"
+func (m *metricsCollector) parseInterfaces(buf []byte, now int64) ([]proto.Message, error) {
+	var rows []proto.Message
+	for _, line := range strings.Split(string(buf), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		row := InterfaceStats{Timestamp: now}
+		var err error
+		for _, tok := range fields[1:] {
+			// single-letter flags come before the properties, e.g. "R" for running
+			if !strings.Contains(tok, "=") {
+				row.Running = row.Running || strings.Contains(tok, "R")
+				row.Disabled = row.Disabled || strings.Contains(tok, "X")
+				continue
+			}
+
+			if v, ok := hasPrefix(tok, "name="); ok {
+				row.Name = strings.Trim(v, `"`)
+			} else if v, ok := hasPrefix(tok, "rx-byte="); ok {
+				row.RxBytes, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "tx-byte="); ok {
+				row.TxBytes, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "rx-packet="); ok {
+				row.RxPackets, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "tx-packet="); ok {
+				row.TxPackets, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "rx-error="); ok {
+				row.RxErrors, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "tx-error="); ok {
+				row.TxErrors, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "rx-drop="); ok {
+				row.RxDrops, err = parseInt(v)
+			} else if v, ok := hasPrefix(tok, "tx-drop="); ok {
+				row.TxDrops, err = parseInt(v)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error parsing %q: %w", tok, err)
+			}
+		}
+
+		if row.Name == "" {
+			continue
+		}
+		if len(m.interfaces) > 0 && !m.interfaces[row.Name] {
+			continue
+		}
+		rows = append(rows, &row)
+	}
+	return rows, nil
+}
+
+// parseDHCPClients parses the output of "/ip dhcp-client print terse"
+func parseDHCPClients(buf []byte, now int64) ([]proto.Message, error) {
+	var rows []proto.Message
+	for _, line := range strings.Split(string(buf), "\n") {
+		row := DHCPClient{Timestamp: now}
+		for _, tok := range strings.Fields(line) {
+			if v, ok := hasPrefix(tok, "interface="); ok {
+				row.Interface = v
+			} else if v, ok := hasPrefix(tok, "status="); ok {
+				row.Status = v
+			} else if v, ok := hasPrefix(tok, "address="); ok {
+				row.Address = v
+			} else if v, ok := hasPrefix(tok, "gateway="); ok {
+				row.Gateway = v
+			} else if v, ok := hasPrefix(tok, "expires-after="); ok {
+				d, err := parseRouterDuration(v)
+				if err != nil {
+					return nil, fmt.Errorf("error parsing %q: %w", tok, err)
+				}
+				row.ExpiresAfter = int64(d.Seconds())
+			}
+		}
+		if row.Interface != "" {
+			rows = append(rows, &row)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,575 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.19.1
+// source: metrics.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RouterResource struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp     int64 `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`         // microseconds since epoch
+	Uptime        int64 `protobuf:"varint,20,opt,name=Uptime,proto3" json:"Uptime,omitempty"`               // seconds since the router booted
+	CPULoad       int64 `protobuf:"varint,30,opt,name=CPULoad,proto3" json:"CPULoad,omitempty"`             // percent
+	FreeMemory    int64 `protobuf:"varint,40,opt,name=FreeMemory,proto3" json:"FreeMemory,omitempty"`       // bytes
+	TotalMemory   int64 `protobuf:"varint,50,opt,name=TotalMemory,proto3" json:"TotalMemory,omitempty"`     // bytes
+	FreeHDDSpace  int64 `protobuf:"varint,60,opt,name=FreeHDDSpace,proto3" json:"FreeHDDSpace,omitempty"`   // bytes
+	TotalHDDSpace int64 `protobuf:"varint,70,opt,name=TotalHDDSpace,proto3" json:"TotalHDDSpace,omitempty"` // bytes
+}
+
+func (x *RouterResource) Reset() {
+	*x = RouterResource{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouterResource) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouterResource) ProtoMessage() {}
+
+func (x *RouterResource) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouterResource.ProtoReflect.Descriptor instead.
+func (*RouterResource) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RouterResource) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *RouterResource) GetUptime() int64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *RouterResource) GetCPULoad() int64 {
+	if x != nil {
+		return x.CPULoad
+	}
+	return 0
+}
+
+func (x *RouterResource) GetFreeMemory() int64 {
+	if x != nil {
+		return x.FreeMemory
+	}
+	return 0
+}
+
+func (x *RouterResource) GetTotalMemory() int64 {
+	if x != nil {
+		return x.TotalMemory
+	}
+	return 0
+}
+
+func (x *RouterResource) GetFreeHDDSpace() int64 {
+	if x != nil {
+		return x.FreeHDDSpace
+	}
+	return 0
+}
+
+func (x *RouterResource) GetTotalHDDSpace() int64 {
+	if x != nil {
+		return x.TotalHDDSpace
+	}
+	return 0
+}
+
+type RouterHealth struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp int64   `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // microseconds since epoch
+	Name      string  `protobuf:"bytes,20,opt,name=Name,proto3" json:"Name,omitempty"`            // e.g. "temperature" or "voltage"
+	Value     float64 `protobuf:"fixed64,30,opt,name=Value,proto3" json:"Value,omitempty"`
+	Unit      string  `protobuf:"bytes,40,opt,name=Unit,proto3" json:"Unit,omitempty"` // e.g. "C" or "V"
+}
+
+func (x *RouterHealth) Reset() {
+	*x = RouterHealth{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RouterHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RouterHealth) ProtoMessage() {}
+
+func (x *RouterHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RouterHealth.ProtoReflect.Descriptor instead.
+func (*RouterHealth) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RouterHealth) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *RouterHealth) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RouterHealth) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *RouterHealth) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+// the counters are cumulative since the router booted
+type InterfaceStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp int64  `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // microseconds since epoch
+	Name      string `protobuf:"bytes,20,opt,name=Name,proto3" json:"Name,omitempty"`
+	Running   bool   `protobuf:"varint,30,opt,name=Running,proto3" json:"Running,omitempty"` // link is up
+	Disabled  bool   `protobuf:"varint,40,opt,name=Disabled,proto3" json:"Disabled,omitempty"`
+	RxBytes   int64  `protobuf:"varint,50,opt,name=RxBytes,proto3" json:"RxBytes,omitempty"`
+	TxBytes   int64  `protobuf:"varint,60,opt,name=TxBytes,proto3" json:"TxBytes,omitempty"`
+	RxPackets int64  `protobuf:"varint,70,opt,name=RxPackets,proto3" json:"RxPackets,omitempty"`
+	TxPackets int64  `protobuf:"varint,80,opt,name=TxPackets,proto3" json:"TxPackets,omitempty"`
+	RxErrors  int64  `protobuf:"varint,90,opt,name=RxErrors,proto3" json:"RxErrors,omitempty"`
+	TxErrors  int64  `protobuf:"varint,100,opt,name=TxErrors,proto3" json:"TxErrors,omitempty"`
+	RxDrops   int64  `protobuf:"varint,110,opt,name=RxDrops,proto3" json:"RxDrops,omitempty"`
+	TxDrops   int64  `protobuf:"varint,120,opt,name=TxDrops,proto3" json:"TxDrops,omitempty"`
+}
+
+func (x *InterfaceStats) Reset() {
+	*x = InterfaceStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *InterfaceStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InterfaceStats) ProtoMessage() {}
+
+func (x *InterfaceStats) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InterfaceStats.ProtoReflect.Descriptor instead.
+func (*InterfaceStats) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *InterfaceStats) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *InterfaceStats) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *InterfaceStats) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+func (x *InterfaceStats) GetRxBytes() int64 {
+	if x != nil {
+		return x.RxBytes
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetTxBytes() int64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetRxPackets() int64 {
+	if x != nil {
+		return x.RxPackets
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetTxPackets() int64 {
+	if x != nil {
+		return x.TxPackets
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetRxErrors() int64 {
+	if x != nil {
+		return x.RxErrors
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetTxErrors() int64 {
+	if x != nil {
+		return x.TxErrors
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetRxDrops() int64 {
+	if x != nil {
+		return x.RxDrops
+	}
+	return 0
+}
+
+func (x *InterfaceStats) GetTxDrops() int64 {
+	if x != nil {
+		return x.TxDrops
+	}
+	return 0
+}
+
+type DHCPClient struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp    int64  `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // microseconds since epoch
+	Interface    string `protobuf:"bytes,20,opt,name=Interface,proto3" json:"Interface,omitempty"`
+	Status       string `protobuf:"bytes,30,opt,name=Status,proto3" json:"Status,omitempty"`   // e.g. "bound" or "searching..."
+	Address      string `protobuf:"bytes,40,opt,name=Address,proto3" json:"Address,omitempty"` // address assigned by the upstream DHCP server, in CIDR form
+	Gateway      string `protobuf:"bytes,50,opt,name=Gateway,proto3" json:"Gateway,omitempty"`
+	ExpiresAfter int64  `protobuf:"varint,60,opt,name=ExpiresAfter,proto3" json:"ExpiresAfter,omitempty"` // seconds until the lease expires
+}
+
+func (x *DHCPClient) Reset() {
+	*x = DHCPClient{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_metrics_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DHCPClient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DHCPClient) ProtoMessage() {}
+
+func (x *DHCPClient) ProtoReflect() protoreflect.Message {
+	mi := &file_metrics_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DHCPClient.ProtoReflect.Descriptor instead.
+func (*DHCPClient) Descriptor() ([]byte, []int) {
+	return file_metrics_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DHCPClient) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *DHCPClient) GetInterface() string {
+	if x != nil {
+		return x.Interface
+	}
+	return ""
+}
+
+func (x *DHCPClient) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *DHCPClient) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *DHCPClient) GetGateway() string {
+	if x != nil {
+		return x.Gateway
+	}
+	return ""
+}
+
+func (x *DHCPClient) GetExpiresAfter() int64 {
+	if x != nil {
+		return x.ExpiresAfter
+	}
+	return 0
+}
+
+var File_metrics_proto protoreflect.FileDescriptor
+
+var file_metrics_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x08, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0xec, 0x01, 0x0a, 0x0e, 0x52, 0x6f,
+	0x75, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09,
+	0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x55, 0x70,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x55, 0x70, 0x74, 0x69,
+	0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x43, 0x50, 0x55, 0x4c, 0x6f, 0x61, 0x64, 0x18, 0x1e, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x07, 0x43, 0x50, 0x55, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x1e, 0x0a, 0x0a,
+	0x46, 0x72, 0x65, 0x65, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x18, 0x28, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x0a, 0x46, 0x72, 0x65, 0x65, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x12, 0x20, 0x0a, 0x0b,
+	0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x18, 0x32, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x0b, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x4d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x12, 0x22,
+	0x0a, 0x0c, 0x46, 0x72, 0x65, 0x65, 0x48, 0x44, 0x44, 0x53, 0x70, 0x61, 0x63, 0x65, 0x18, 0x3c,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x46, 0x72, 0x65, 0x65, 0x48, 0x44, 0x44, 0x53, 0x70, 0x61,
+	0x63, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x48, 0x44, 0x44, 0x53, 0x70,
+	0x61, 0x63, 0x65, 0x18, 0x46, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x54, 0x6f, 0x74, 0x61, 0x6c,
+	0x48, 0x44, 0x44, 0x53, 0x70, 0x61, 0x63, 0x65, 0x22, 0x6a, 0x0a, 0x0c, 0x52, 0x6f, 0x75, 0x74,
+	0x65, 0x72, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x14,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x55, 0x6e, 0x69, 0x74, 0x18, 0x28, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
+	0x55, 0x6e, 0x69, 0x74, 0x22, 0xd4, 0x02, 0x0a, 0x0e, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61,
+	0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x14, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x75, 0x6e,
+	0x6e, 0x69, 0x6e, 0x67, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x52, 0x75, 0x6e, 0x6e,
+	0x69, 0x6e, 0x67, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x18,
+	0x28, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x44, 0x69, 0x73, 0x61, 0x62, 0x6c, 0x65, 0x64, 0x12,
+	0x18, 0x0a, 0x07, 0x52, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x32, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x07, 0x52, 0x78, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x54, 0x78, 0x42,
+	0x79, 0x74, 0x65, 0x73, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x54, 0x78, 0x42, 0x79,
+	0x74, 0x65, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73,
+	0x18, 0x46, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x52, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
+	0x73, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x50,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x78, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12,
+	0x1a, 0x0a, 0x08, 0x52, 0x78, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x5a, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x08, 0x52, 0x78, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x54,
+	0x78, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x64, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x54,
+	0x78, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x52, 0x78, 0x44, 0x72, 0x6f,
+	0x70, 0x73, 0x18, 0x6e, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x52, 0x78, 0x44, 0x72, 0x6f, 0x70,
+	0x73, 0x12, 0x18, 0x0a, 0x07, 0x54, 0x78, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x18, 0x78, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x07, 0x54, 0x78, 0x44, 0x72, 0x6f, 0x70, 0x73, 0x22, 0xb8, 0x01, 0x0a, 0x0a,
+	0x44, 0x48, 0x43, 0x50, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x1c, 0x0a, 0x09, 0x49, 0x6e, 0x74, 0x65,
+	0x72, 0x66, 0x61, 0x63, 0x65, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x49, 0x6e, 0x74,
+	0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x18, 0x1e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x28, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x47, 0x61, 0x74, 0x65,
+	0x77, 0x61, 0x79, 0x18, 0x32, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x47, 0x61, 0x74, 0x65, 0x77,
+	0x61, 0x79, 0x12, 0x22, 0x0a, 0x0c, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65, 0x73, 0x41, 0x66, 0x74,
+	0x65, 0x72, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x45, 0x78, 0x70, 0x69, 0x72, 0x65,
+	0x73, 0x41, 0x66, 0x74, 0x65, 0x72, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d, 0x61, 0x69, 0x6e,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_metrics_proto_rawDescOnce sync.Once
+	file_metrics_proto_rawDescData = file_metrics_proto_rawDesc
+)
+
+func file_metrics_proto_rawDescGZIP() []byte {
+	file_metrics_proto_rawDescOnce.Do(func() {
+		file_metrics_proto_rawDescData = protoimpl.X.CompressGZIP(file_metrics_proto_rawDescData)
+	})
+	return file_metrics_proto_rawDescData
+}
+
+var file_metrics_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_metrics_proto_goTypes = []interface{}{
+	(*RouterResource)(nil), // 0: tutorial.RouterResource
+	(*RouterHealth)(nil),   // 1: tutorial.RouterHealth
+	(*InterfaceStats)(nil), // 2: tutorial.InterfaceStats
+	(*DHCPClient)(nil),     // 3: tutorial.DHCPClient
+}
+var file_metrics_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_metrics_proto_init() }
+func file_metrics_proto_init() {
+	if File_metrics_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_metrics_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouterResource); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RouterHealth); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*InterfaceStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_metrics_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DHCPClient); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_metrics_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_metrics_proto_goTypes,
+		DependencyIndexes: file_metrics_proto_depIdxs,
+		MessageInfos:      file_metrics_proto_msgTypes,
+	}.Build()
+	File_metrics_proto = out.File
+	file_metrics_proto_rawDesc = nil
+	file_metrics_proto_goTypes = nil
+	file_metrics_proto_depIdxs = nil
+}
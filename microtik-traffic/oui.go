@@ -0,0 +1,44 @@
+package main
+
+import (
+	_ "embed"
+	"strconv"
+	"strings"
+)
+
+// oui.txt contains one "XX:XX:XX<tab>vendor" line per organizationally unique
+// identifier. The checked-in file is a partial list of the vendors that we have
+// seen on our network, so other devices get an empty vendor. Run "make
+// update-oui" to replace it with the full IEEE registry.
+//
+//go:embed oui.txt
+var ouiTable string
+
+var vendorByOUI = parseOUI(ouiTable)
+
+func parseOUI(s string) map[string]string {
+	m := make(map[string]string)
+	for _, line := range strings.Split(s, "\n") {
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		m[strings.ToUpper(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+	return m
+}
+
+// lookupVendor gets the vendor for a MAC address, or the empty string if unknown
+func lookupVendor(mac string) string {
+	if len(mac) < 8 {
+		return ""
+	}
+
+	// addresses with the locally administered bit set are randomized by the device
+	first, err := strconv.ParseUint(mac[:2], 16, 8)
+	if err == nil && first&0x02 != 0 {
+		return "(randomized)"
+	}
+
+	return vendorByOUI[strings.ToUpper(mac[:8])]
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// prefixList is a set of IPv4 CIDR ranges that are considered local
+type prefixList []netip.Prefix
+
+// parsePrefixes parses CIDR ranges and refuses IPv6 ones, since RouterOS only
+// records IPv4 traffic in /ip accounting and an IPv6 range would never match
+
+func parsePrefixes(specs []string) (prefixList, error) {
+	var out prefixList
+	for _, spec := range specs {
+		p, err := netip.ParsePrefix(spec)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing local prefix: %w", err)
+		}
+		if !p.Addr().Is4() {
+			return nil, fmt.Errorf("local prefix %s is not IPv4: /ip accounting does not record IPv6 traffic", spec)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+// match parses the given address and reports whether it is in one of the prefixes
+func (l prefixList) match(s string) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.WithZone("").Unmap()
+	for _, p := range l {
+		if p.Contains(addr) {
+			return addr, true
+		}
+	}
+	return netip.Addr{}, false
+}
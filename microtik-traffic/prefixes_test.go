@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestParsePrefixes(t *testing.T) {
+	local, err := parsePrefixes([]string{"192.168.88.7/24", "10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"192.168.88.1", true},
+		{"192.168.89.1", false},
+		{"10.20.30.40", true},
+		{"::ffff:192.168.88.20", true},
+		{"8.8.8.8", false},
+		{"not an address", false},
+	}
+	for _, c := range cases {
+		_, ok := local.match(c.addr)
+		if ok != c.want {
+			t.Errorf("match(%q): expected %v, got %v", c.addr, c.want, ok)
+		}
+	}
+}
+
+func TestParsePrefixesRefusesIPv6(t *testing.T) {
+	for _, spec := range []string{"fd00::/64", "::ffff:192.168.88.0/120", "192.168.88.0"} {
+		_, err := parsePrefixes([]string{"192.168.88.0/24", spec})
+		if err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestLastPart(t *testing.T) {
+	for ip, want := range map[string]string{"192.168.88.23": "23", "10.0.0.1": "1"} {
+		if got := lastPart(ip); got != want {
+			t.Errorf("lastPart(%q): expected %q, got %q", ip, want, got)
+		}
+	}
+}
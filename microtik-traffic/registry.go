@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Device is a physical device on the network, identified by its MAC address so
+// that it keeps the same identity across DHCP lease and hostname changes
+type Device struct {
+	ID        int64     // stable identifier, never re-used
+	MAC       string    // upper-case, colon-separated
+	Hostname  string    // most recent DHCP hostname
+	Owner     string    // assigned by a human by editing the registry file
+	Label     string    // assigned by a human by editing the registry file
+	Vendor    string    // looked up from the OUI table when the device was first seen
+	FirstSeen time.Time // first time traffic was seen from this device
+	LastSeen  time.Time // most recent time traffic was seen from this device
+}
+
+// registry is a set of devices that is persisted to a JSON file. The file can be
+// edited by hand to assign owners and labels; it is re-read on every tick, and
+// again before saving so that edits made during a tick are kept.
+type registry struct {
+	path  string
+	next  int64
+	byMAC map[string]*Device
+	seen  map[string]sighting // devices observed since the registry was loaded, by MAC
+}
+
+// sighting is the most recent observation of a device
+type sighting struct {
+	hostname string
+	at       time.Time
+}
+
+// newRegistry creates an empty registry that will be saved to the given path
+func newRegistry(path string) *registry {
+	return &registry{
+		path:  path,
+		next:  1,
+		byMAC: make(map[string]*Device),
+		seen:  make(map[string]sighting),
+	}
+}
+
+// loadRegistry reads the registry from the given path. A missing file is treated
+// as an empty registry.
+func loadRegistry(path string) (*registry, error) {
+	r := newRegistry(path)
+
+	buf, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []*Device
+	err = json.Unmarshal(buf, &devices)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	for _, d := range devices {
+		d.MAC = strings.ToUpper(d.MAC)
+		if _, dup := r.byMAC[d.MAC]; dup {
+			return nil, fmt.Errorf("%s contains %s more than once", path, d.MAC)
+		}
+		r.byMAC[d.MAC] = d
+		if d.ID >= r.next {
+			r.next = d.ID + 1
+		}
+	}
+	return r, nil
+}
+
+// observe records that the device with the given MAC address was seen, adding
+// it to the registry if necessary
+func (r *registry) observe(mac, hostname string, at time.Time) *Device {
+	mac = strings.ToUpper(mac)
+	d, ok := r.byMAC[mac]
+	if !ok {
+		d = &Device{
+			ID:        r.next,
+			MAC:       mac,
+			Vendor:    lookupVendor(mac),
+			FirstSeen: at,
+		}
+		r.byMAC[mac] = d
+		r.next++
+	}
+	if hostname != "" {
+		d.Hostname = hostname
+	}
+	d.LastSeen = at
+	r.seen[mac] = sighting{hostname: hostname, at: at}
+	return d
+}
+
+// save re-reads the registry file, applies the devices observed since it was
+// loaded, and writes it back, so that owners and labels assigned by hand in
+// the meantime are not overwritten. A device that is new to the file keeps the
+// ID it was given unless that ID has since been taken.
+func (r *registry) save() error {
+	current, err := loadRegistry(r.path)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[int64]bool)
+	for _, d := range current.byMAC {
+		ids[d.ID] = true
+	}
+	for mac, s := range r.seen {
+		if _, ok := current.byMAC[mac]; !ok && !ids[r.byMAC[mac].ID] {
+			d := *r.byMAC[mac]
+			current.byMAC[mac] = &d
+			if d.ID >= current.next {
+				current.next = d.ID + 1
+			}
+		}
+		ids[current.observe(mac, s.hostname, s.at).ID] = true
+	}
+
+	err = current.write()
+	if err != nil {
+		return err
+	}
+
+	*r = *current
+	r.seen = make(map[string]sighting)
+	return nil
+}
+
+// write writes the registry to its file, sorted by device ID
+func (r *registry) write() error {
+	var devices []*Device
+	for _, d := range r.byMAC {
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool {
+		return devices[i].ID < devices[j].ID
+	})
+
+	buf, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename so that a crash never leaves a partial file
+	tmp := r.path + ".tmp"
+	err = os.WriteFile(tmp, buf, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistryKeepsEditsMadeDuringTick(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	// an earlier tick saw the synology
+	r := newRegistry(path)
+	r.observe("90:09:d0:00:60:b7", "synology", start)
+	if err := r.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// this tick loads the registry and sees the synology again and a new laptop
+	tick, err := loadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := start.Add(time.Minute)
+	tick.observe("90:09:d0:00:60:b7", "", later)
+	laptop := tick.observe("3c:22:fb:11:22:33", "pixelbook", later)
+
+	// meanwhile someone labels the synology and adds a device by hand, taking
+	// the ID that the laptop was given
+	edit, err := loadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit.byMAC["90:09:D0:00:60:B7"].Owner = "ops"
+	edit.byMAC["90:09:D0:00:60:B7"].Label = "nas"
+	edit.byMAC["AA:BB:CC:DD:EE:FF"] = &Device{ID: laptop.ID, MAC: "AA:BB:CC:DD:EE:FF", Label: "printer"}
+	if err := edit.write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tick.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := loadRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.byMAC) != 3 {
+		t.Fatalf("expected 3 devices, got %d", len(saved.byMAC))
+	}
+
+	nas := saved.byMAC["90:09:D0:00:60:B7"]
+	if nas.Owner != "ops" || nas.Label != "nas" {
+		t.Errorf("expected the hand edits to be kept, got owner=%q label=%q", nas.Owner, nas.Label)
+	}
+	if nas.Hostname != "synology" || !nas.LastSeen.Equal(later) || !nas.FirstSeen.Equal(start) {
+		t.Errorf("expected the sighting to be applied, got %+v", nas)
+	}
+
+	printer := saved.byMAC["AA:BB:CC:DD:EE:FF"]
+	if printer.Label != "printer" || printer.ID != laptop.ID {
+		t.Errorf("expected the hand-added device to be kept, got %+v", printer)
+	}
+
+	pixelbook := saved.byMAC["3C:22:FB:11:22:33"]
+	if pixelbook == nil {
+		t.Fatal("expected the new device to be saved")
+	}
+	if pixelbook.ID == printer.ID {
+		t.Errorf("expected the new device to get a free ID, both have %d", pixelbook.ID)
+	}
+	if pixelbook.Hostname != "pixelbook" || !pixelbook.FirstSeen.Equal(later) {
+		t.Errorf("unexpected new device %+v", pixelbook)
+	}
+}
+
+func TestRegistrySaveRefusesBrokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "devices.json")
+	r := newRegistry(path)
+	r.observe("90:09:d0:00:60:b7", "synology", time.Now())
+	if err := r.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a typo in a hand edit must not be overwritten
+	if err := os.WriteFile(path, []byte("[{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.save(); err == nil {
+		t.Error("expected an error saving over a file that does not parse")
+	}
+}
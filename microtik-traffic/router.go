@@ -0,0 +1,85 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+)
+
+// routerClient runs RouterOS commands and returns their output. It is
+// implemented by routeros.Client.
+type routerClient interface {
+	Output(cmd string) ([]byte, error)
+	Close() error
+}
+
+type DHCPLease struct {
+	IP       string
+	MAC      string
+	Hostname string
+}
+
+type Traffic struct {
+	From    string
+	To      string
+	Packets int
+	Bytes   int
+}
+
+// parseLeases parses the output of "/ip dhcp-server lease print terse"
+func parseLeases(buf []byte) []DHCPLease {
+	var leases []DHCPLease
+	for _, line := range strings.Split(string(buf), "\n") {
+		var item DHCPLease
+		for _, tok := range strings.Split(line, " ") {
+			if v, ok := hasPrefix(tok, "address="); ok {
+				item.IP = v
+			}
+			if v, ok := hasPrefix(tok, "mac-address="); ok {
+				item.MAC = v
+			}
+			if v, ok := hasPrefix(tok, "host-name="); ok {
+				item.Hostname = v
+			}
+		}
+		var zero DHCPLease
+		if item != zero {
+			leases = append(leases, item)
+		}
+	}
+	return leases
+}
+
+// parseTraffic parses the output of "/ip accounting snapshot print terse"
+func parseTraffic(buf []byte) []Traffic {
+	var err error
+	var traffic []Traffic
+	for _, line := range strings.Split(string(buf), "\n") {
+		var item Traffic
+		for _, tok := range strings.Split(line, " ") {
+			if v, ok := hasPrefix(tok, "src-address="); ok {
+				item.From = v
+			}
+			if v, ok := hasPrefix(tok, "dst-address="); ok {
+				item.To = v
+			}
+			if v, ok := hasPrefix(tok, "packets="); ok {
+				item.Packets, err = strconv.Atoi(v)
+				if err != nil {
+					log.Println(err)
+				}
+			}
+			if v, ok := hasPrefix(tok, "bytes="); ok {
+				item.Bytes, err = strconv.Atoi(v)
+				if err != nil {
+					log.Println(err)
+				}
+			}
+		}
+		var zero Traffic
+		if item != zero {
+			traffic = append(traffic, item)
+		}
+	}
+	return traffic
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRouterDuration parses a duration as printed by RouterOS, such as
+// "1w2d03:04:05" (RouterOS 6) or "1w2d3h4m5s" (RouterOS 7)
+func parseRouterDuration(s string) (time.Duration, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	for s != "" {
+		// find the leading number
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 || i == len(s) {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+
+		// the remainder may be a clock time like 03:04:05
+		if s[i] == ':' {
+			parts := strings.Split(s, ":")
+			if len(parts) != 3 {
+				return 0, fmt.Errorf("invalid duration %q", orig)
+			}
+			var hms [3]int
+			for j, part := range parts {
+				n, err := strconv.Atoi(part)
+				if err != nil {
+					return 0, fmt.Errorf("invalid duration %q", orig)
+				}
+				hms[j] = n
+			}
+			total += time.Duration(hms[0])*time.Hour +
+				time.Duration(hms[1])*time.Minute +
+				time.Duration(hms[2])*time.Second
+			break
+		}
+
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+		d := time.Duration(n)
+		s = s[i:]
+
+		switch {
+		case strings.HasPrefix(s, "ms"):
+			total += d * time.Millisecond
+			s = s[2:]
+		case s[0] == 'w':
+			total += d * 7 * 24 * time.Hour
+			s = s[1:]
+		case s[0] == 'd':
+			total += d * 24 * time.Hour
+			s = s[1:]
+		case s[0] == 'h':
+			total += d * time.Hour
+			s = s[1:]
+		case s[0] == 'm':
+			total += d * time.Minute
+			s = s[1:]
+		case s[0] == 's':
+			total += d * time.Second
+			s = s[1:]
+		default:
+			return 0, fmt.Errorf("invalid duration %q", orig)
+		}
+	}
+	return total, nil
+}
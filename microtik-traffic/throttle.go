@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/monasticacademy/maple-network-tools/rsc"
+)
+
+// throttleComment marks the simple queues that are managed by this tool so that
+// we never touch queues that were added by hand
+const throttleComment = "microtik-traffic"
+
+// listQueuesCommand lists the simple queues that are managed by this tool
+const listQueuesCommand = `/queue simple print terse where comment="` + throttleComment + `"`
+
+// sample is the number of bytes used by one device during one tick
+type sample struct {
+	At    time.Time
+	Bytes int64
+}
+
+// throttler adds a simple queue on the router for each device whose usage over a
+// rolling window exceeds a limit, and removes the queue once usage falls back.
+//
+// Note that fasttracked connections bypass simple queues on RouterOS, so the
+// fasttrack rule in the forward chain must be disabled for queues to have an effect.
+type throttler struct {
+	limit    int64         // number of bytes per window above which a device is throttled
+	window   time.Duration // rolling window over which usage is summed
+	maxLimit string        // max-limit for the simple queue, e.g. "1M/5M" (upload/download)
+	dryRun   bool          // if true then log intended actions but do not run them
+
+	history   map[string][]sample // recent usage samples keyed by local IP address
+	throttled map[string]string   // max-limit of each queue that we added in dry run mode, keyed by queue name
+}
+
+func newThrottler(limit int64, window time.Duration, maxLimit string, dryRun bool) *throttler {
+	return &throttler{
+		limit:     limit,
+		window:    window,
+		maxLimit:  maxLimit,
+		dryRun:    dryRun,
+		history:   make(map[string][]sample),
+		throttled: make(map[string]string),
+	}
+}
+
+// record adds a usage sample for the given local IP address
+func (t *throttler) record(at time.Time, ip string, bytes int64) {
+	t.history[ip] = append(t.history[ip], sample{At: at, Bytes: bytes})
+}
+
+// usage sums the samples within the rolling window for each IP address and
+// drops samples that have fallen out of the window
+func (t *throttler) usage(now time.Time) map[string]int64 {
+	totals := make(map[string]int64)
+	for ip, samples := range t.history {
+		var keep []sample
+		for _, s := range samples {
+			if now.Sub(s.At) <= t.window {
+				keep = append(keep, s)
+				totals[ip] += s.Bytes
+			}
+		}
+		if len(keep) == 0 {
+			delete(t.history, ip)
+		} else {
+			t.history[ip] = keep
+		}
+	}
+	return totals
+}
+
+// queueName gets the name of the simple queue for the given IP address
+func queueName(ip string) string {
+	return "throttle-" + ip
+}
+
+// parseQueues gets the max-limit of each managed queue from "/queue simple
+// print terse", keyed by queue name
+func parseQueues(buf []byte) map[string]string {
+	queues := make(map[string]string)
+	for _, line := range strings.Split(string(buf), "\n") {
+		var name, maxLimit string
+		for _, tok := range strings.Split(line, " ") {
+			if v, ok := hasPrefix(tok, "name="); ok {
+				name = strings.Trim(v, `"`)
+			}
+			if v, ok := hasPrefix(tok, "max-limit="); ok {
+				maxLimit = strings.Trim(v, `"`)
+			}
+		}
+		if name != "" {
+			queues[name] = maxLimit
+		}
+	}
+	return queues
+}
+
+// update compares usage over the rolling window to the queues that currently
+// exist on the router, then adds, updates, or removes queues where the state
+// of a device has changed. The run function executes a single RouterOS command
+// over SSH.
+func (t *throttler) update(now time.Time, run func(cmd string) ([]byte, error)) error {
+	// fetch the queues that we added previously, possibly in an earlier process
+	buf, err := run(listQueuesCommand)
+	if err != nil {
+		return fmt.Errorf("error listing simple queues: %w", err)
+	}
+	existing := parseQueues(buf)
+
+	// in dry run mode the router never changes, so remember what we would have done
+	if t.dryRun {
+		for name, maxLimit := range t.throttled {
+			existing[name] = maxLimit
+		}
+	}
+
+	// decide what to do with each device
+	var cmds []string
+	var added, removed []string
+	totals := t.usage(now)
+	for ip, bytes := range totals {
+		name := queueName(ip)
+		maxLimit, ok := existing[name]
+		delete(existing, name)
+
+		switch {
+		case bytes > t.limit && !ok:
+			cmds = append(cmds, fmt.Sprintf(`/queue simple add name=%s target=%s max-limit=%s comment=%s`,
+				rsc.Quote(name), ip, rsc.Quote(t.maxLimit), rsc.Quote(throttleComment)))
+			added = append(added, name)
+			log.Printf("throttling %s to %s (%d bytes in the past %v)", ip, t.maxLimit, bytes, t.window)
+		case bytes > t.limit && maxLimit != t.maxLimit:
+			cmds = append(cmds, fmt.Sprintf(`/queue simple set [find name=%s] max-limit=%s`,
+				rsc.Quote(name), rsc.Quote(t.maxLimit)))
+			added = append(added, name)
+			log.Printf("changing throttle for %s from %s to %s", ip, maxLimit, t.maxLimit)
+		case bytes <= t.limit && ok:
+			cmds = append(cmds, fmt.Sprintf(`/queue simple remove [find name=%s]`, rsc.Quote(name)))
+			removed = append(removed, name)
+			log.Printf("removing throttle for %s (%d bytes in the past %v)", ip, bytes, t.window)
+		}
+	}
+
+	// remove queues for devices that have had no traffic at all within the window
+	for name := range existing {
+		cmds = append(cmds, fmt.Sprintf(`/queue simple remove [find name=%s]`, rsc.Quote(name)))
+		removed = append(removed, name)
+		log.Printf("removing throttle %s (no traffic in the past %v)", name, t.window)
+	}
+
+	for _, cmd := range cmds {
+		if t.dryRun {
+			log.Println("dry run, not running:", cmd)
+			continue
+		}
+		_, err := run(cmd)
+		if err != nil {
+			return fmt.Errorf("error running %q: %w", cmd, err)
+		}
+	}
+
+	if t.dryRun {
+		for _, name := range added {
+			t.throttled[name] = t.maxLimit
+		}
+		for _, name := range removed {
+			delete(t.throttled, name)
+		}
+	}
+	return nil
+}
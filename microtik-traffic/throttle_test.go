@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeQueues is a router that only knows about managed simple queues
+type fakeQueues struct {
+	queues map[string]string // max-limit keyed by queue name
+	cmds   []string          // commands other than listing the queues
+}
+
+func (f *fakeQueues) run(cmd string) ([]byte, error) {
+	if cmd == listQueuesCommand {
+		var b strings.Builder
+		for name, maxLimit := range f.queues {
+			b.WriteString(` 0    name="` + name + `" max-limit=` + maxLimit + " comment=" + throttleComment + "\n")
+		}
+		return []byte(b.String()), nil
+	}
+
+	f.cmds = append(f.cmds, cmd)
+	name := strings.SplitN(strings.SplitN(cmd, `name="`, 2)[1], `"`, 2)[0]
+	switch {
+	case strings.HasPrefix(cmd, "/queue simple add"):
+		f.queues[name] = "1M/5M"
+	case strings.HasPrefix(cmd, "/queue simple remove"):
+		delete(f.queues, name)
+	}
+	return nil, nil
+}
+
+func TestThrottleActsOnlyOnChanges(t *testing.T) {
+	router := fakeQueues{queues: make(map[string]string)}
+	th := newThrottler(1000, time.Hour, "1M/5M", false)
+	now := time.Now()
+
+	// first tick: over the limit, so a queue is added
+	th.record(now, "192.168.88.41", 2000)
+	if err := th.update(now, router.run); err != nil {
+		t.Fatal(err)
+	}
+	if len(router.cmds) != 1 || !strings.HasPrefix(router.cmds[0], "/queue simple add") {
+		t.Fatalf("expected one add, got %q", router.cmds)
+	}
+
+	// second tick: still over the limit, so nothing changes
+	router.cmds = nil
+	now = now.Add(10 * time.Minute)
+	th.record(now, "192.168.88.41", 10)
+	if err := th.update(now, router.run); err != nil {
+		t.Fatal(err)
+	}
+	if len(router.cmds) != 0 {
+		t.Fatalf("expected no commands for an already throttled host, got %q", router.cmds)
+	}
+
+	// once the usage falls out of the window the queue is removed, once
+	now = now.Add(2 * time.Hour)
+	for i := 0; i < 2; i++ {
+		router.cmds = nil
+		th.record(now, "192.168.88.41", 10)
+		if err := th.update(now, router.run); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	if len(router.queues) != 0 || len(router.cmds) != 0 {
+		t.Fatalf("expected the queue to be removed once, got queues %v and commands %q", router.queues, router.cmds)
+	}
+}
+
+func TestThrottleDryRunActsOnlyOnChanges(t *testing.T) {
+	router := fakeQueues{queues: make(map[string]string)}
+	th := newThrottler(1000, time.Hour, "1M/5M", true)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		th.record(now, "192.168.88.41", 2000)
+		if err := th.update(now, router.run); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	if len(router.cmds) != 0 {
+		t.Fatalf("dry run should not run commands, got %q", router.cmds)
+	}
+	if th.throttled[queueName("192.168.88.41")] != "1M/5M" {
+		t.Fatalf("dry run should remember the throttled host, got %v", th.throttled)
+	}
+}
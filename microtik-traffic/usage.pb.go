@@ -31,6 +31,9 @@ type Usage struct {
 	MAC      string `protobuf:"bytes,40,opt,name=MAC,proto3" json:"MAC,omitempty"`
 	Bytes    int64  `protobuf:"varint,50,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
 	Packets  int64  `protobuf:"varint,60,opt,name=Packets,proto3" json:"Packets,omitempty"`
+	DeviceID int64  `protobuf:"varint,70,opt,name=DeviceID,proto3" json:"DeviceID,omitempty"` // stable identifier from the device registry, or zero if the MAC is unknown
+	Label    string `protobuf:"bytes,80,opt,name=Label,proto3" json:"Label,omitempty"`        // human-assigned label from the device registry
+	Partial  bool   `protobuf:"varint,90,opt,name=Partial,proto3" json:"Partial,omitempty"`   // true if some traffic in this window was lost, e.g. because the router rebooted
 }
 
 func (x *Usage) Reset() {
@@ -107,11 +110,32 @@ func (x *Usage) GetPackets() int64 {
 	return 0
 }
 
+func (x *Usage) GetDeviceID() int64 {
+	if x != nil {
+		return x.DeviceID
+	}
+	return 0
+}
+
+func (x *Usage) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *Usage) GetPartial() bool {
+	if x != nil {
+		return x.Partial
+	}
+	return false
+}
+
 var File_usage_proto protoreflect.FileDescriptor
 
 var file_usage_proto_rawDesc = []byte{
 	0x0a, 0x0b, 0x75, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x74,
-	0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x8f, 0x01, 0x0a, 0x05, 0x55, 0x73, 0x61, 0x67,
+	0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0xdb, 0x01, 0x0a, 0x05, 0x55, 0x73, 0x61, 0x67,
 	0x65, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03,
 	0x52, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x75, 0x72, 0x61, 0x74,
 	0x69, 0x6f, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x44, 0x75, 0x72, 0x61, 0x74,
@@ -120,8 +144,13 @@ var file_usage_proto_rawDesc = []byte{
 	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x4d, 0x41, 0x43, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x79, 0x74,
 	0x65, 0x73, 0x18, 0x32, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12,
 	0x18, 0x0a, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d,
-	0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x52, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x49, 0x44, 0x18, 0x46, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x44, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x49, 0x44, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x50,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x50,
+	0x61, 0x72, 0x74, 0x69, 0x61, 0x6c, 0x18, 0x5a, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x50, 0x61,
+	0x72, 0x74, 0x69, 0x61, 0x6c, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
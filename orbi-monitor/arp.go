@@ -2,7 +2,9 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"strings"
@@ -21,21 +23,32 @@ type arpTable struct {
 	entries []arpEntry
 }
 
-// adapted from https://github.com/mostlygeek/arp
-func loadARPTable() (*arpTable, error) {
-	f, err := os.Open("/proc/net/arp")
+// loadARPTable reads an ARP table in the format of /proc/net/arp
+func loadARPTable(path string) (*arpTable, error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return parseARPTable(f)
+}
 
-	s := bufio.NewScanner(f)
+// parseARPTable parses the contents of /proc/net/arp. Incomplete entries, which
+// are neighbors that have not answered an ARP request yet, have an all-zero
+// hardware address and are skipped.
+//
+// adapted from https://github.com/mostlygeek/arp
+func parseARPTable(r io.Reader) (*arpTable, error) {
+	s := bufio.NewScanner(r)
 	s.Scan() // skip the field descriptions
 
 	var table arpTable
 	for s.Scan() {
 		line := s.Text()
 		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
 		if len(fields) != 6 {
 			return nil, fmt.Errorf("expected 6 fields but got %d: %q", len(fields), strings.TrimSpace(line))
 		}
@@ -49,6 +62,9 @@ func loadARPTable() (*arpTable, error) {
 		if err != nil {
 			return nil, fmt.Errorf("error parsing MAC address: %w", err)
 		}
+		if isZeroMAC(hw) {
+			continue
+		}
 
 		table.entries = append(table.entries, arpEntry{
 			IPAddr:       ip,
@@ -59,6 +75,14 @@ func loadARPTable() (*arpTable, error) {
 			Device:       fields[5],
 		})
 	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
 
 	return &table, nil
 }
+
+// isZeroMAC reports whether a hardware address is 00:00:00:00:00:00
+func isZeroMAC(hw net.HardwareAddr) bool {
+	return len(hw) == 0 || bytes.Count(hw, []byte{0}) == len(hw)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseARPTable(t *testing.T) {
+	table, err := loadARPTable("testdata/arp.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the incomplete entries for .37 and .54 are skipped
+	want := []struct {
+		ip, mac, flags, device string
+	}{
+		{"192.168.88.1", "48:8f:5a:3c:11:02", "0x2", "br0"},
+		{"192.168.88.250", "90:09:d0:00:60:b7", "0x2", "br0"},
+		{"192.168.88.131", "78:45:58:ea:e9:26", "0x2", "br0"},
+		{"192.168.88.201", "da:a1:19:6e:02:4c", "0x2", "br0"},
+		{"192.168.88.22", "b4:22:00:50:0a:7f", "0x6", "br0"},
+	}
+	if len(table.entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d: %v", len(want), len(table.entries), table.entries)
+	}
+	for i, w := range want {
+		e := table.entries[i]
+		if e.IPAddr.String() != w.ip || e.HardwareAddr.String() != w.mac || e.Flags != w.flags || e.Device != w.device {
+			t.Errorf("entry %d: got %s %s %s %s, want %s %s %s %s", i,
+				e.IPAddr, e.HardwareAddr, e.Flags, e.Device, w.ip, w.mac, w.flags, w.device)
+		}
+	}
+}
+
+func TestParseARPTableErrors(t *testing.T) {
+	const header = "IP address       HW type     Flags       HW address            Mask     Device\n"
+	for _, line := range []string{
+		"192.168.88.1     0x1         0x2         48:8f:5a:3c:11:02     *",
+		"192.168.88      0x1         0x2         48:8f:5a:3c:11:02     *        br0",
+		"192.168.88.1     0x1         0x2         48:8f:5a:3c:11        *        br0",
+	} {
+		_, err := parseARPTable(strings.NewReader(header + line + "\n"))
+		if err == nil {
+			t.Errorf("expected an error for %q", line)
+		}
+	}
+}
+
+func TestParseARPTableOnlyIncomplete(t *testing.T) {
+	const input = `IP address       HW type     Flags       HW address            Mask     Device
+192.168.88.37    0x1         0x0         00:00:00:00:00:00     *        br0
+
+`
+	table, err := parseARPTable(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.entries) != 0 {
+		t.Errorf("expected no entries, got %v", table.entries)
+	}
+}
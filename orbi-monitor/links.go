@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// satellites maps the addresses of our Orbi router and satellites to their
+// names, such as "main hall"
+type satellites map[string]string
+
+// loadSatellites reads a file with one "<address> <name>" pair per line, where
+// the name may contain spaces. Blank lines and lines starting with # are
+// ignored.
+func loadSatellites(path string) (satellites, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sats := make(satellites)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected an address and a name: %q", line)
+		}
+		hw, err := net.ParseMAC(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing MAC address: %w", err)
+		}
+		sats[macKey(hw)] = strings.TrimSpace(fields[1])
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return sats, nil
+}
+
+// isBackhaul determines whether a frame was sent from one satellite to
+// another. Frames with all four addresses are relayed between access points,
+// so these count as backhaul even if the satellites are not in the list.
+func (s satellites) isBackhaul(frame *layers.Dot11) bool {
+	if frame.Flags.ToDS() && frame.Flags.FromDS() {
+		return true
+	}
+	_, fromSatellite := s[macKey(frame.Address2)]
+	_, toSatellite := s[macKey(frame.Address1)]
+	return fromSatellite && toSatellite
+}
+
+// linkTraffic holds the statistics for each pair of stations. For backhaul
+// traffic the link is between the transmitting and receiving satellites, and
+// for client traffic it is between the original source and final destination.
+type linkTraffic map[link]*statistics
+
+// count adds a data frame to the statistics for its link
+func (t linkTraffic) count(frame *layers.Dot11, aliases macAliases, sats satellites) {
+	if frame.Type.MainType() != layers.Dot11TypeData {
+		return
+	}
+
+	var key link
+	if sats.isBackhaul(frame) {
+		key = link{From: macKey(frame.Address2), To: macKey(frame.Address1), Backhaul: true}
+	} else {
+		src, dst := frameEndpoints(frame)
+		key = link{From: aliases.resolve(src), To: aliases.resolve(dst)}
+	}
+
+	stats := t[key]
+	if stats == nil {
+		stats = new(statistics)
+		t[key] = stats
+	}
+	stats.Packets += 1
+	stats.Bytes += int64(len(frame.Payload))
+}
+
+// rows converts the statistics collected over the window starting at begin to
+// bigquery rows, with the busiest links first
+func (t linkTraffic) rows(begin time.Time, duration time.Duration, neighbors neighborTable, sats satellites) []*LinkStats {
+	// label each end of a link with its satellite name or IP address
+	label := func(mac string) string {
+		if name, ok := sats[mac]; ok {
+			return name
+		}
+		if ip, ok := neighbors[mac]; ok {
+			return ip.String()
+		}
+		return ""
+	}
+
+	var rows []*LinkStats
+	for k, v := range t {
+		rows = append(rows, &LinkStats{
+			Begin:     begin.UnixMicro(),
+			Duration:  duration.Milliseconds(),
+			From:      k.From,
+			To:        k.To,
+			FromLabel: label(k.From),
+			ToLabel:   label(k.To),
+			Backhaul:  k.Backhaul,
+			Bytes:     v.Bytes,
+			Packets:   v.Packets,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Bytes != rows[j].Bytes {
+			return rows[i].Bytes > rows[j].Bytes
+		}
+		if rows[i].From != rows[j].From {
+			return rows[i].From < rows[j].From
+		}
+		return rows[i].To < rows[j].To
+	})
+	return rows
+}
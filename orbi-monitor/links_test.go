@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+func TestLinkRows(t *testing.T) {
+	router := mustMAC(t, "9c:3d:cf:10:20:31")
+	satellite := mustMAC(t, "9c:3d:cf:10:20:41")
+	gateway := mustMAC(t, "48:8f:5a:3c:11:02")
+	laptop := mustMAC(t, "78:45:58:ea:e9:26")
+	tv := mustMAC(t, "b4:22:00:50:0a:7f")
+
+	sats := satellites{macKey(router): "orbi router", macKey(satellite): "main hall"}
+	neighbors := neighborTable{
+		macKey(gateway): net.ParseIP("192.168.88.1"),
+		macKey(laptop):  net.ParseIP("192.168.88.131"),
+	}
+
+	frames := []layers.Dot11{
+		// the laptop sends to the gateway through the router
+		{Type: layers.Dot11TypeData, Flags: layers.Dot11FlagsToDS, Address1: router, Address2: laptop, Address3: gateway},
+		// the router relays traffic for the tv to the satellite
+		{Type: layers.Dot11TypeData, Flags: layers.Dot11FlagsToDS | layers.Dot11FlagsFromDS,
+			Address1: satellite, Address2: router, Address3: tv, Address4: gateway},
+		{Type: layers.Dot11TypeData, Flags: layers.Dot11FlagsToDS | layers.Dot11FlagsFromDS,
+			Address1: satellite, Address2: router, Address3: tv, Address4: gateway},
+		// acknowledgements are not counted
+		{Type: layers.Dot11TypeCtrlAck, Address1: laptop},
+	}
+	frames[0].Payload = make([]byte, 100)
+	frames[1].Payload = make([]byte, 400)
+	frames[2].Payload = make([]byte, 500)
+
+	traffic := make(linkTraffic)
+	for i := range frames {
+		traffic.count(&frames[i], make(macAliases), sats)
+	}
+
+	begin := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	rows := traffic.rows(begin, 10*time.Second, neighbors, sats)
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 links, got %d: %v", len(rows), rows)
+	}
+
+	// the busiest link comes first
+	backhaul, client := rows[0], rows[1]
+	if !backhaul.Backhaul || backhaul.FromLabel != "orbi router" || backhaul.ToLabel != "main hall" ||
+		backhaul.Bytes != 900 || backhaul.Packets != 2 {
+		t.Errorf("unexpected backhaul row: %v", backhaul)
+	}
+	if client.Backhaul || client.FromLabel != "192.168.88.131" || client.ToLabel != "192.168.88.1" ||
+		client.Bytes != 100 || client.Packets != 1 {
+		t.Errorf("unexpected client row: %v", client)
+	}
+	if client.Begin != begin.UnixMicro() || client.Duration != 10000 {
+		t.Errorf("unexpected window: begin %d, duration %d", client.Begin, client.Duration)
+	}
+}
@@ -0,0 +1,16 @@
+-- Convert the Begin column of router_traffic from the string that older
+-- versions of orbi-monitor sent, such as
+--
+--   2022-03-04 05:06:07.891011 -0800 PST m=+1234.567890
+--
+-- to a timestamp. Older versions took Begin at the end of each window, so we
+-- subtract the duration to get the start of the window. Run this with
+-- "make migrate-begin", which first copies the table to router_traffic_backup
+-- and afterwards adds any columns that the table is missing.
+
+CREATE OR REPLACE TABLE maple.router_traffic AS
+SELECT * REPLACE (
+  TIMESTAMP_SUB(
+    PARSE_TIMESTAMP('%Y-%m-%d %H:%M:%E*S %z', REGEXP_EXTRACT(Begin, r'^(\S+ \S+ [-+]\d{4})')),
+    INTERVAL Duration MILLISECOND) AS Begin)
+FROM maple.router_traffic_backup;
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/mdlayher/netlink"
+	"github.com/mdlayher/netlink/nlenc"
+	"golang.org/x/sys/unix"
+)
+
+// neighborUpdate is a change to the kernel's IP to MAC mapping
+type neighborUpdate struct {
+	IPAddr       net.IP
+	HardwareAddr net.HardwareAddr // may be nil for deletions
+	Deleted      bool
+}
+
+// neighborTable maps hardware addresses to IP addresses, keyed by macKey
+type neighborTable map[string]net.IP
+
+// load replaces the contents of the table with an ARP table, and returns the
+// number of addresses that were not in the table before
+func (t neighborTable) load(arp *arpTable) int {
+	prev := make(map[string]bool)
+	for k := range t {
+		prev[k] = true
+		delete(t, k)
+	}
+
+	var added int
+	for _, entry := range arp.entries {
+		k := macKey(entry.HardwareAddr)
+		if !prev[k] {
+			added++
+		}
+		t[k] = entry.IPAddr
+	}
+	return added
+}
+
+// apply updates the table with a single change, and reports whether anything
+// changed
+func (t neighborTable) apply(u neighborUpdate) bool {
+	if !u.Deleted {
+		k := macKey(u.HardwareAddr)
+		if ip, ok := t[k]; ok && ip.Equal(u.IPAddr) {
+			return false
+		}
+		t[k] = u.IPAddr
+		return true
+	}
+
+	// deletions do not always include the hardware address
+	if len(u.HardwareAddr) > 0 {
+		k := macKey(u.HardwareAddr)
+		_, ok := t[k]
+		delete(t, k)
+		return ok
+	}
+	var changed bool
+	for k, ip := range t {
+		if ip.Equal(u.IPAddr) {
+			delete(t, k)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// watchNeighbors subscribes to rtnetlink neighbor events and sends them on the
+// returned channel. The channel is closed if the subscription fails, after
+// which the caller should fall back to polling.
+func watchNeighbors(ctx context.Context) (<-chan neighborUpdate, error) {
+	conn, err := netlink.Dial(unix.NETLINK_ROUTE, &netlink.Config{Groups: unix.RTMGRP_NEIGH})
+	if err != nil {
+		return nil, fmt.Errorf("error subscribing to neighbor events: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	updates := make(chan neighborUpdate, 64)
+	go func() {
+		defer close(updates)
+		for {
+			msgs, err := conn.Receive()
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Println("error receiving neighbor events: ", err)
+				}
+				return
+			}
+			for _, msg := range msgs {
+				if u, ok := parseNeighborMessage(msg); ok {
+					updates <- u
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
+
+// parseNeighborMessage parses an RTM_NEWNEIGH or RTM_DELNEIGH message, which
+// consists of a struct ndmsg followed by attributes. Only IPv4 neighbors are
+// reported since /proc/net/arp only contains IPv4 neighbors.
+func parseNeighborMessage(msg netlink.Message) (neighborUpdate, bool) {
+	var u neighborUpdate
+	switch msg.Header.Type {
+	case unix.RTM_NEWNEIGH:
+	case unix.RTM_DELNEIGH:
+		u.Deleted = true
+	default:
+		return u, false
+	}
+
+	if len(msg.Data) < unix.SizeofNdMsg || msg.Data[0] != unix.AF_INET {
+		return u, false
+	}
+	state := nlenc.Uint16(msg.Data[8:10])
+
+	ad, err := netlink.NewAttributeDecoder(msg.Data[unix.SizeofNdMsg:])
+	if err != nil {
+		return u, false
+	}
+	for ad.Next() {
+		switch ad.Type() {
+		case unix.NDA_DST:
+			u.IPAddr = net.IP(ad.Bytes())
+		case unix.NDA_LLADDR:
+			u.HardwareAddr = net.HardwareAddr(ad.Bytes())
+		}
+	}
+	if ad.Err() != nil || u.IPAddr == nil {
+		return u, false
+	}
+
+	// a neighbor that stopped answering is as good as deleted, and one that has
+	// not answered yet has no usable hardware address
+	if state&unix.NUD_FAILED != 0 {
+		u.Deleted = true
+	}
+	if !u.Deleted && (state&unix.NUD_INCOMPLETE != 0 || isZeroMAC(u.HardwareAddr)) {
+		return u, false
+	}
+	return u, true
+}
@@ -7,77 +7,118 @@ import (
 	_ "embed"
 	"fmt"
 	"log"
-	"net"
+	"os"
 	"time"
 
-	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
-	"cloud.google.com/go/logging"
 	"github.com/alexflint/go-arg"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcapgo"
-	"github.com/kr/pretty"
+	"github.com/monasticacademy/maple-network-tools/pcapfile"
+	"github.com/monasticacademy/maple-network-tools/sink"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 	"google.golang.org/protobuf/proto"
 
 	storage "cloud.google.com/go/bigquery/storage/apiv1beta2"
-	storagepb "google.golang.org/genproto/googleapis/cloud/bigquery/storage/v1beta2"
 )
 
 //go:embed secrets/service-account.json
 var googleCredentials []byte
 
-// this is how we match 802.11 packet addresses to the ARP table
-func arpKey(hw net.HardwareAddr) string {
-	if len(hw) != 6 {
-		return "nil"
-	}
-	return fmt.Sprintf("%X:%X", hw[4], hw[5])
-}
-
-func dot11Key(hw net.HardwareAddr) string {
-	if len(hw) != 6 {
-		return "nil"
-	}
-	return fmt.Sprintf("%X:%X", hw[0], hw[1])
-}
-
-// logEntry is the data that send to cloud logging once per N seconds
-type logEntry struct {
-	From    string
-	To      string
-	Bytes   int64
-	Packets int64
-}
+const streamingTraceID = "orbi-monitor" // identifies this client in bigquery debug logs
 
 // link represents a from and to address
 type link struct {
-	From string
-	To   string
+	From     string
+	To       string
+	Backhaul bool
 }
 
 // statistics represents the statistics we keep for each from/to address
 type statistics struct {
-	Packets int64 // number of packets observed
-	Bytes   int64 // number of bytes in all observed wifi frames
+	Packets       int64 // number of packets observed
+	Bytes         int64 // number of bytes in all observed wifi frames
+	BytesSent     int64 // number of bytes in frames from the station
+	BytesReceived int64 // number of bytes in frames to the station
 }
 
 func main() {
 	ctx := context.Background()
 
 	var args struct {
-		Interface string `arg:"positional,required"`
-		LogName   string
-		Dataset   string
-		Table     string
-		Interval  time.Duration
+		Interface  string `arg:"positional"`
+		Dataset    string
+		Table      string
+		LinkTable  string `help:"bigquery table for the traffic between each pair of stations"`
+		Interval   time.Duration
+		ARPTable   string        `help:"ARP table to read, in the format of /proc/net/arp"`
+		Neighbors  string        `help:"how to keep the ARP table up to date: netlink or poll"`
+		ARPPoll    time.Duration `help:"interval at which to reload the ARP table when polling"`
+		PrintARP   bool          `help:"print the ARP table and exit"`
+		MACMap     string        `help:"file mapping 802.11 addresses to ARP table addresses, one pair per line"`
+		PcapFile   string        `arg:"--pcap-file" help:"read packets from a pcap or pcapng file and print the rows instead of sending them to bigquery"`
+		Satellites string        `help:"file naming the addresses of the Orbi router and satellites, one per line"`
+		Port       string        `help:"address for the top talkers page, such as :8080"`
 	}
-	args.LogName = "orbi-packets"
 	args.Dataset = "maple"
 	args.Table = "router_traffic"
+	args.LinkTable = "router_links"
 	args.Interval = 10 * time.Second
-	arg.MustParse(&args)
+	args.ARPTable = "/proc/net/arp"
+	args.Neighbors = "netlink"
+	args.ARPPoll = 30 * time.Second
+	p := arg.MustParse(&args)
+
+	if args.PrintARP {
+		arpTable, err := loadARPTable(args.ARPTable)
+		if err != nil {
+			log.Fatal("error loading ARP table: ", err)
+		}
+		for _, entry := range arpTable.entries {
+			fmt.Printf("%-20v %-20v %v\n", entry.HardwareAddr, entry.IPAddr, entry.Device)
+		}
+		return
+	}
+	if args.Interface == "" && args.PcapFile == "" {
+		p.Fail("interface is required")
+	}
+	if args.Neighbors != "netlink" && args.Neighbors != "poll" {
+		p.Fail("--neighbors must be netlink or poll")
+	}
+
+	// load the mapping for rewritten and randomized 802.11 addresses
+	aliases := make(macAliases)
+	if args.MACMap != "" {
+		var err error
+		aliases, err = loadMACAliases(args.MACMap)
+		if err != nil {
+			log.Fatal("error loading MAC address mapping: ", err)
+		}
+		log.Printf("loaded %d MAC address mappings", len(aliases))
+	}
+
+	// aggregate the packets in a capture file and print the rows
+	if args.PcapFile != "" {
+		arpTable, err := loadARPTable(args.ARPTable)
+		if err != nil {
+			log.Fatal("error loading ARP table: ", err)
+		}
+		neighbors := make(neighborTable)
+		neighbors.load(arpTable)
+
+		f, pkgsrc, err := pcapfile.Open(args.PcapFile)
+		if err != nil {
+			log.Fatal("error opening capture file: ", err)
+		}
+		defer f.Close()
+
+		err = replay(pkgsrc, neighbors, aliases, args.Interval, os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	// unpack google credentials
 	creds, err := google.CredentialsFromJSON(ctx, googleCredentials)
@@ -88,20 +129,9 @@ func main() {
 	log.Println("project:", creds.ProjectID)
 	log.Println("dataset:", args.Dataset)
 	log.Println("table:", args.Table)
+	log.Println("link table:", args.LinkTable)
 	log.Println("log interval:", args.Interval)
 
-	// create the logger
-	logClient, err := logging.NewClient(ctx,
-		creds.ProjectID,
-		option.WithCredentialsJSON(googleCredentials))
-	if err != nil {
-		log.Fatal("error creating logging client: ", err)
-	}
-	defer logClient.Close()
-
-	lg := logClient.Logger(args.LogName)
-	_ = lg
-
 	// create the bigquery client for stream insertion
 	bqClient, err := storage.NewBigQueryWriteClient(ctx,
 		option.WithCredentialsJSON(googleCredentials))
@@ -110,56 +140,74 @@ func main() {
 	}
 	defer bqClient.Close()
 
-	// create the write stream
-	parent := fmt.Sprintf("projects/%s/datasets/%s/tables/%s", creds.ProjectID, args.Dataset, args.Table)
-	resp, err := bqClient.CreateWriteStream(ctx, &storagepb.CreateWriteStreamRequest{
-		Parent: parent,
-		WriteStream: &storagepb.WriteStream{
-			Type: storagepb.WriteStream_COMMITTED,
-		},
-	})
+	// create the write streams, which retry rows that fail to send
+	out, err := sink.NewBigQuery(ctx, bqClient, creds.ProjectID, args.Dataset, args.Table, &Traffic{}, streamingTraceID)
 	if err != nil {
-		log.Fatal("CreateWriteStream: ", err)
+		log.Fatal(err)
 	}
-
-	// get the stream
-	bqStream, err := bqClient.AppendRows(ctx)
+	linkOut, err := sink.NewBigQuery(ctx, bqClient, creds.ProjectID, args.Dataset, args.LinkTable, &LinkStats{}, streamingTraceID)
 	if err != nil {
-		log.Fatal("AppendRows: ", err)
+		log.Fatal(err)
 	}
 
-	// initialize options for protobuf marshalling
-	var protoMarshal proto.MarshalOptions
-
-	// eep protobuf is crazy
-	var traffic Traffic
-	trafficDescriptor, err := adapt.NormalizeDescriptor(traffic.ProtoReflect().Descriptor())
-	if err != nil {
-		log.Fatal("NormalizeDescriptor: ", err)
+	// load the addresses of the Orbi router and satellites, so that backhaul
+	// traffic can be told apart from client traffic
+	sats := make(satellites)
+	if args.Satellites != "" {
+		sats, err = loadSatellites(args.Satellites)
+		if err != nil {
+			log.Fatal("error loading satellites: ", err)
+		}
+		log.Printf("loaded %d satellites", len(sats))
 	}
 
-	// open ARP table (TODO: should reload this periodically)
-	arpTable, err := loadARPTable()
+	// open ARP table
+	arpTable, err := loadARPTable(args.ARPTable)
 	if err != nil {
 		log.Fatal("error loading ARP table: ", err)
 	}
-	ipByMAC := make(map[string]net.IP)
+	ipByMAC := make(neighborTable)
+	ipByMAC.load(arpTable)
 	for _, entry := range arpTable.entries {
-		ipByMAC[arpKey(entry.HardwareAddr)] = entry.IPAddr
 		log.Printf("  %20v %20v", entry.HardwareAddr, entry.IPAddr)
 	}
 	log.Printf("loaded %d MAC addresses from ARP table", len(ipByMAC))
 
+	// keep the ARP table up to date, either from netlink events or by polling,
+	// so that devices that join after startup are counted too
+	var neighborUpdates <-chan neighborUpdate
+	var arpTicker *time.Ticker
+	if args.Neighbors == "netlink" {
+		neighborUpdates, err = watchNeighbors(ctx)
+		if err != nil {
+			log.Printf("%v, falling back to polling %s every %v", err, args.ARPTable, args.ARPPoll)
+		}
+	}
+	if neighborUpdates == nil {
+		arpTicker = time.NewTicker(args.ARPPoll)
+	} else {
+		// a ticker that never fires, so that the select below need not check for nil
+		arpTicker = time.NewTicker(time.Hour)
+		arpTicker.Stop()
+	}
+
 	// open packet capture handle
 	handle, err := pcapgo.NewEthernetHandle(args.Interface)
 	if err != nil {
 		log.Fatal("error creating ethernet handle: ", err)
 	}
 
-	statsBySource := make(map[string]*statistics)
-	// statsByLink := make(map[link]*statistics)
+	statsByStation := make(stationTraffic)
+	statsByLink := make(linkTraffic)
+
+	// serve the busiest links from the most recent interval
+	var talkers topTalkers
+	if args.Port != "" {
+		go talkers.runWebUI(args.Port)
+	}
 
 	logTicker := time.NewTicker(args.Interval)
+	windowStart := time.Now()
 	var lastDump time.Time
 
 	var packets, bytes, nonwifi int64
@@ -175,76 +223,66 @@ outer:
 			log.Println()
 			log.Printf("%10d bytes over %10d packets (plus %d non-wifi)", packets, bytes, nonwifi)
 
+			// the rows cover the window since the previous tick, which may be
+			// longer than the interval if the previous send was slow
 			now := time.Now()
+			duration := now.Sub(windowStart)
 
-			var bqRows, bqBytes int
-			var rowData [][]byte
-			for k, v := range statsBySource {
-				log.Printf("  %15v %10d bytes over %10d packets", k, v.Bytes, v.Packets)
-
-				buf, err := protoMarshal.Marshal(&Traffic{
-					Begin:     now.String(),
-					Duration:  args.Interval.Milliseconds(),
-					IPAddress: k,
-					Bytes:     v.Bytes,
-					Packets:   v.Packets,
-				})
-				if err != nil {
-					log.Fatal("protobuf.Marshal: ", err)
-				}
-
-				bqRows += 1
-				bqBytes += len(buf)
-				rowData = append(rowData, buf)
+			var rows []proto.Message
+			for _, row := range statsByStation.rows(windowStart, duration) {
+				log.Printf("  %15v %10d bytes over %10d packets (%d up, %d down)",
+					row.IPAddress, row.Bytes, row.Packets, row.BytesSent, row.BytesReceived)
+				rows = append(rows, row)
 			}
 
-			// append the rows
-			err = bqStream.Send(&storagepb.AppendRowsRequest{
-				WriteStream: resp.Name,
-				TraceId:     "orbi-monitor", // identifies this client
-				Rows: &storagepb.AppendRowsRequest_ProtoRows{
-					ProtoRows: &storagepb.AppendRowsRequest_ProtoData{
-						// protocol buffer schema
-						WriterSchema: &storagepb.ProtoSchema{
-							ProtoDescriptor: trafficDescriptor,
-						},
-						// protocol buffer data
-						Rows: &storagepb.ProtoRows{
-							SerializedRows: rowData, // serialized protocol buffer data
-						},
-					},
-				},
-			})
+			err = out.Write(ctx, rows)
 			if err != nil {
-				log.Fatal("AppendRows.Send: ", err)
+				log.Println("error sending rows to bigquery: ", err)
 			}
 
-			resp, err := bqStream.Recv()
+			links := statsByLink.rows(windowStart, duration, ipByMAC, sats)
+			var linkRows []proto.Message
+			for _, row := range links {
+				linkRows = append(linkRows, row)
+			}
+			err = linkOut.Write(ctx, linkRows)
 			if err != nil {
-				log.Fatal("AppendRows.Recv: ", err)
+				log.Println("error sending link rows to bigquery: ", err)
+			} else {
+				log.Printf("sent %d link rows", len(links))
 			}
-			pretty.Println("AppendRows response was: ", resp.GetResponse())
-
-			log.Printf("sent %d rows (%d bytes) to bigquery", bqRows, bqBytes)
-
-			// for k, v := range statsByLink {
-			// lg.Log(logging.Entry{
-			// 	Payload: logEntry{
-			// 		From:   k.From,
-			// 		To:     k.To,
-			// 		Frames: v.Frames,
-			// 		Bytes:  v.Bytes,
-			// 	},
-			// })
-			// }
+			talkers.update(windowStart, duration, links)
+			windowStart = now
 
-			// log.Printf("uploaded %d log entries\n", len(statsByLink))
-
-			// TODO: delete the entries one-by-one to reduce memory churn
-			// statsByLink = make(map[link]*statistics)
 			bytes = 0
 			packets = 0
-			statsBySource = make(map[string]*statistics)
+			statsByStation = make(stationTraffic)
+			statsByLink = make(linkTraffic)
+
+		case u, ok := <-neighborUpdates:
+			if !ok {
+				log.Printf("neighbor events stopped, falling back to polling %s every %v", args.ARPTable, args.ARPPoll)
+				neighborUpdates = nil
+				arpTicker.Reset(args.ARPPoll)
+				break
+			}
+			if ipByMAC.apply(u) {
+				if u.Deleted {
+					log.Printf("neighbor %v %v is gone", u.HardwareAddr, u.IPAddr)
+				} else {
+					log.Printf("neighbor %v is at %v", u.HardwareAddr, u.IPAddr)
+				}
+			}
+
+		case <-arpTicker.C:
+			arpTable, err := loadARPTable(args.ARPTable)
+			if err != nil {
+				log.Println("error reloading ARP table: ", err)
+				break
+			}
+			if added := ipByMAC.load(arpTable); added > 0 {
+				log.Printf("reloaded ARP table, %d new MAC addresses (%d total)", added, len(ipByMAC))
+			}
 
 		case packet := <-pkgsrc.Packets():
 
@@ -290,27 +328,8 @@ outer:
 			// 	}
 			// }
 
-			macs := []net.HardwareAddr{p.Address1, p.Address2, p.Address3, p.Address4}
-			for _, mac := range macs {
-				if len(mac) != 6 {
-					continue
-				}
-
-				var ip net.IP
-				var found bool
-				if ip, found = ipByMAC[dot11Key(mac)]; !found {
-					continue
-				}
-				//log.Printf("known hardware address found at position %d (%v)", i, ip)
-
-				stats, found := statsBySource[ip.String()]
-				if !found {
-					stats = new(statistics)
-					statsBySource[ip.String()] = stats
-				}
-				stats.Bytes += int64(len(p.Payload))
-				stats.Packets += 1
-			}
+			statsByStation.count(p, ipByMAC, aliases)
+			statsByLink.count(p, aliases, sats)
 
 			packets += 1
 			bytes += int64(len(p.Payload))
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/monasticacademy/maple-network-tools/pcapfile"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// replay aggregates the packets from a capture file over intervals of capture
+// time, rather than wall clock time, and prints the rows that would have been
+// sent to bigquery as JSON, one per line
+func replay(pkgsrc *gopacket.PacketSource, neighbors neighborTable, aliases macAliases, interval time.Duration, w io.Writer) error {
+	stats := make(stationTraffic)
+	add := func(packet gopacket.Packet) {
+		frame, ok := packet.Layer(layers.LayerTypeDot11).(*layers.Dot11)
+		if ok {
+			stats.count(frame, neighbors, aliases)
+		}
+	}
+	flush := func(begin, end time.Time) error {
+		for _, row := range stats.rows(begin, end.Sub(begin)) {
+			buf, err := protojson.Marshal(row)
+			if err != nil {
+				return fmt.Errorf("protojson.Marshal: %w", err)
+			}
+			_, err = fmt.Fprintf(w, "%s\n", buf)
+			if err != nil {
+				return err
+			}
+		}
+		stats = make(stationTraffic)
+		return nil
+	}
+	return pcapfile.Replay(pkgsrc, interval, add, flush)
+}
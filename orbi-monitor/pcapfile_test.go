@@ -0,0 +1,80 @@
+//go:generate go run ../pcapfile/gencaptures orbi-monitor
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monasticacademy/maple-network-tools/pcapfile"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// replayFile replays a capture in 10 second intervals against the ARP table in
+// testdata and returns the printed rows
+func replayFile(t *testing.T, path string) string {
+	t.Helper()
+	arp, err := loadARPTable("testdata/arp.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	neighbors := make(neighborTable)
+	neighbors.load(arp)
+
+	f, src, err := pcapfile.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var b bytes.Buffer
+	err = replay(src, neighbors, make(macAliases), 10*time.Second, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+// The two captures in testdata hold the same synthetic frames, the pcap without
+// radiotap headers and the pcapng with them
+func TestReplayPcapAndPcapng(t *testing.T) {
+	pcap := replayFile(t, "testdata/capture.pcap")
+	pcapng := replayFile(t, "testdata/capture.pcapng")
+
+	if pcap != pcapng {
+		t.Errorf("expected the same rows from the pcap and pcapng captures\npcap:\n%s\npcapng:\n%s", pcap, pcapng)
+	}
+
+	var found, foundLast bool
+	for _, line := range strings.Split(strings.TrimSpace(pcap), "\n") {
+		var row Traffic
+		if err := protojson.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatal(err)
+		}
+
+		// the last interval starts at 20s and ends at the last packet, at 26s
+		wantDuration := int64(10000)
+		if row.Begin == 1790856020000000 {
+			foundLast = true
+			wantDuration = 6000
+		}
+		if row.Duration != wantDuration {
+			t.Errorf("expected a duration of %dms, got %v", wantDuration, &row)
+		}
+
+		// the laptop sent 1200 bytes and received 3000 in the first interval
+		if row.IPAddress == "192.168.88.131" && row.Begin == 1790856000000000 {
+			found = true
+			if row.Bytes != 4200 || row.Packets != 3 || row.BytesSent != 1200 || row.BytesReceived != 3000 {
+				t.Errorf("unexpected row for the laptop: %v", &row)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a row for the laptop in the first interval, got:\n%s", pcap)
+	}
+	if !foundLast {
+		t.Errorf("expected rows in the last interval, got:\n%s", pcap)
+	}
+}
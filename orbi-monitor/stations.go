@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// macKey is how hardware addresses from the ARP table and from 802.11 frames
+// are matched to each other
+func macKey(hw net.HardwareAddr) string {
+	if len(hw) != 6 {
+		return ""
+	}
+	return hw.String()
+}
+
+// macAliases maps addresses seen in 802.11 frames to the addresses that appear
+// in the ARP table. The Orbi rewrites the addresses of stations behind its
+// satellites, and some stations use randomized addresses over the air, so
+// these cannot always be matched directly.
+type macAliases map[string]string
+
+// loadMACAliases reads a file with one "<802.11 address> <ARP address>" pair
+// per line. Blank lines and lines starting with # are ignored.
+func loadMACAliases(path string) (macAliases, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	aliases := make(macAliases)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("expected 2 fields but got %d: %q", len(fields), line)
+		}
+		from, err := net.ParseMAC(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing MAC address: %w", err)
+		}
+		to, err := net.ParseMAC(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing MAC address: %w", err)
+		}
+		aliases[macKey(from)] = macKey(to)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return aliases, nil
+}
+
+// resolve gets the ARP table key for an address seen in an 802.11 frame
+func (a macAliases) resolve(hw net.HardwareAddr) string {
+	k := macKey(hw)
+	if to, ok := a[k]; ok {
+		return to
+	}
+	return k
+}
+
+// frameEndpoints gets the original source and final destination of an 802.11
+// frame. Which of the four address fields hold these depends on the ToDS and
+// FromDS flags:
+//
+//	ToDS FromDS  Address1  Address2  Address3  Address4
+//	0    0       dst       src       BSSID     -
+//	1    0       BSSID     src       dst       -
+//	0    1       dst       BSSID     src       -
+//	1    1       receiver  sender    dst       src
+func frameEndpoints(frame *layers.Dot11) (src, dst net.HardwareAddr) {
+	switch {
+	case frame.Flags.ToDS() && frame.Flags.FromDS():
+		return frame.Address4, frame.Address3
+	case frame.Flags.ToDS():
+		return frame.Address2, frame.Address3
+	case frame.Flags.FromDS():
+		return frame.Address3, frame.Address1
+	default:
+		return frame.Address2, frame.Address1
+	}
+}
+
+// stationTraffic holds the statistics for each station, keyed by IP address
+type stationTraffic map[string]*statistics
+
+// get gets the statistics for an IP address, creating them if necessary
+func (t stationTraffic) get(ip net.IP) *statistics {
+	stats, found := t[ip.String()]
+	if !found {
+		stats = new(statistics)
+		t[ip.String()] = stats
+	}
+	return stats
+}
+
+// rows converts the statistics collected over the window starting at begin to
+// bigquery rows, ordered by IP address
+func (t stationTraffic) rows(begin time.Time, duration time.Duration) []*Traffic {
+	var rows []*Traffic
+	for ip, stats := range t {
+		rows = append(rows, &Traffic{
+			Begin:         begin.UnixMicro(),
+			Duration:      duration.Milliseconds(),
+			IPAddress:     ip,
+			Bytes:         stats.Bytes,
+			Packets:       stats.Packets,
+			BytesSent:     stats.BytesSent,
+			BytesReceived: stats.BytesReceived,
+		})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].IPAddress < rows[j].IPAddress
+	})
+	return rows
+}
+
+// count adds a frame to the statistics for its source and destination
+// stations, and reports whether either of them was found in the neighbor
+// table. Only data frames are counted since management and control frames do
+// not carry traffic for the stations.
+func (t stationTraffic) count(frame *layers.Dot11, neighbors neighborTable, aliases macAliases) bool {
+	if frame.Type.MainType() != layers.Dot11TypeData {
+		return false
+	}
+
+	size := int64(len(frame.Payload))
+	src, dst := frameEndpoints(frame)
+
+	var found bool
+	if ip, ok := neighbors[aliases.resolve(src)]; ok {
+		stats := t.get(ip)
+		stats.Packets += 1
+		stats.Bytes += size
+		stats.BytesSent += size
+		found = true
+	}
+	if ip, ok := neighbors[aliases.resolve(dst)]; ok {
+		stats := t.get(ip)
+		stats.Packets += 1
+		stats.Bytes += size
+		stats.BytesReceived += size
+		found = true
+	}
+	return found
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+func mustMAC(t *testing.T, s string) net.HardwareAddr {
+	t.Helper()
+	hw, err := net.ParseMAC(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hw
+}
+
+func TestFrameEndpoints(t *testing.T) {
+	a1 := mustMAC(t, "02:00:00:00:00:01")
+	a2 := mustMAC(t, "02:00:00:00:00:02")
+	a3 := mustMAC(t, "02:00:00:00:00:03")
+	a4 := mustMAC(t, "02:00:00:00:00:04")
+
+	tests := []struct {
+		name     string
+		flags    layers.Dot11Flags
+		src, dst net.HardwareAddr
+	}{
+		{"within a BSS", 0, a2, a1},
+		{"to the distribution system", layers.Dot11FlagsToDS, a2, a3},
+		{"from the distribution system", layers.Dot11FlagsFromDS, a3, a1},
+		{"wireless distribution system", layers.Dot11FlagsToDS | layers.Dot11FlagsFromDS, a4, a3},
+	}
+	for _, test := range tests {
+		frame := layers.Dot11{
+			Flags:    test.flags,
+			Address1: a1,
+			Address2: a2,
+			Address3: a3,
+			Address4: a4,
+		}
+		src, dst := frameEndpoints(&frame)
+		if src.String() != test.src.String() || dst.String() != test.dst.String() {
+			t.Errorf("%s: got %v -> %v, want %v -> %v", test.name, src, dst, test.src, test.dst)
+		}
+	}
+}
+
+func TestMACKey(t *testing.T) {
+	tests := []struct {
+		hw   net.HardwareAddr
+		want string
+	}{
+		{mustMAC(t, "90:09:d0:00:60:b7"), "90:09:d0:00:60:b7"},
+		{mustMAC(t, "90:09:D0:00:60:B7"), "90:09:d0:00:60:b7"},
+		// stations from the same vendor share a prefix, so the whole
+		// address must be part of the key
+		{mustMAC(t, "90:09:d0:00:60:b8"), "90:09:d0:00:60:b8"},
+		{mustMAC(t, "02:00:5e:10:00:00:00:01"), ""},
+		{nil, ""},
+	}
+	for _, test := range tests {
+		if got := macKey(test.hw); got != test.want {
+			t.Errorf("macKey(%v) = %q, want %q", test.hw, got, test.want)
+		}
+	}
+}
+
+func TestCountMatchesFullMAC(t *testing.T) {
+	neighbors := neighborTable{
+		"90:09:d0:00:60:b7": net.ParseIP("192.168.88.250"),
+		"90:09:d0:00:60:b8": net.ParseIP("192.168.88.251"),
+	}
+	aliases := macAliases{"da:a1:19:00:00:01": "90:09:d0:00:60:b8"}
+
+	// a frame from .250 to a randomized address that is aliased to .251
+	frame := layers.Dot11{
+		Type:     layers.Dot11TypeDataQOSData,
+		Address1: mustMAC(t, "da:a1:19:00:00:01"),
+		Address2: mustMAC(t, "90:09:d0:00:60:b7"),
+		Address3: mustMAC(t, "48:8f:5a:3c:11:02"),
+	}
+	frame.Payload = make([]byte, 100)
+
+	traffic := make(stationTraffic)
+	if !traffic.count(&frame, neighbors, aliases) {
+		t.Fatal("expected the frame to match a neighbor")
+	}
+	sender, receiver := traffic["192.168.88.250"], traffic["192.168.88.251"]
+	if sender == nil || sender.BytesSent != 100 || sender.BytesReceived != 0 {
+		t.Errorf("unexpected statistics for the sender: %+v", sender)
+	}
+	if receiver == nil || receiver.BytesReceived != 100 || receiver.BytesSent != 0 {
+		t.Errorf("unexpected statistics for the receiver: %+v", receiver)
+	}
+}
@@ -26,11 +26,13 @@ type Traffic struct {
 	unknownFields protoimpl.UnknownFields
 
 	//google.protobuf.Timestamp Begin = 10;
-	Begin     string `protobuf:"bytes,10,opt,name=Begin,proto3" json:"Begin,omitempty"`
-	Duration  int64  `protobuf:"varint,20,opt,name=Duration,proto3" json:"Duration,omitempty"`
-	IPAddress string `protobuf:"bytes,30,opt,name=IPAddress,proto3" json:"IPAddress,omitempty"`
-	Bytes     int64  `protobuf:"varint,40,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
-	Packets   int64  `protobuf:"varint,50,opt,name=Packets,proto3" json:"Packets,omitempty"`
+	Begin         int64  `protobuf:"varint,10,opt,name=Begin,proto3" json:"Begin,omitempty"` // this is a timestamp in bigquery and we send microseconds since epoch
+	Duration      int64  `protobuf:"varint,20,opt,name=Duration,proto3" json:"Duration,omitempty"`
+	IPAddress     string `protobuf:"bytes,30,opt,name=IPAddress,proto3" json:"IPAddress,omitempty"`
+	Bytes         int64  `protobuf:"varint,40,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	Packets       int64  `protobuf:"varint,50,opt,name=Packets,proto3" json:"Packets,omitempty"`
+	BytesSent     int64  `protobuf:"varint,60,opt,name=BytesSent,proto3" json:"BytesSent,omitempty"`         // bytes in frames from the station
+	BytesReceived int64  `protobuf:"varint,70,opt,name=BytesReceived,proto3" json:"BytesReceived,omitempty"` // bytes in frames to the station
 }
 
 func (x *Traffic) Reset() {
@@ -65,11 +67,11 @@ func (*Traffic) Descriptor() ([]byte, []int) {
 	return file_traffic_proto_rawDescGZIP(), []int{0}
 }
 
-func (x *Traffic) GetBegin() string {
+func (x *Traffic) GetBegin() int64 {
 	if x != nil {
 		return x.Begin
 	}
-	return ""
+	return 0
 }
 
 func (x *Traffic) GetDuration() int64 {
@@ -100,21 +102,165 @@ func (x *Traffic) GetPackets() int64 {
 	return 0
 }
 
+func (x *Traffic) GetBytesSent() int64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+func (x *Traffic) GetBytesReceived() int64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+type LinkStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Begin     int64  `protobuf:"varint,10,opt,name=Begin,proto3" json:"Begin,omitempty"` // this is a timestamp in bigquery and we send microseconds since epoch
+	Duration  int64  `protobuf:"varint,20,opt,name=Duration,proto3" json:"Duration,omitempty"`
+	From      string `protobuf:"bytes,30,opt,name=From,proto3" json:"From,omitempty"`           // MAC address of the transmitting satellite, or of the original source
+	To        string `protobuf:"bytes,40,opt,name=To,proto3" json:"To,omitempty"`               // MAC address of the receiving satellite, or of the final destination
+	FromLabel string `protobuf:"bytes,50,opt,name=FromLabel,proto3" json:"FromLabel,omitempty"` // satellite name or IP address, if known
+	ToLabel   string `protobuf:"bytes,60,opt,name=ToLabel,proto3" json:"ToLabel,omitempty"`     // satellite name or IP address, if known
+	Backhaul  bool   `protobuf:"varint,70,opt,name=Backhaul,proto3" json:"Backhaul,omitempty"`  // whether this is traffic between satellites
+	Bytes     int64  `protobuf:"varint,80,opt,name=Bytes,proto3" json:"Bytes,omitempty"`
+	Packets   int64  `protobuf:"varint,90,opt,name=Packets,proto3" json:"Packets,omitempty"`
+}
+
+func (x *LinkStats) Reset() {
+	*x = LinkStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_traffic_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LinkStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LinkStats) ProtoMessage() {}
+
+func (x *LinkStats) ProtoReflect() protoreflect.Message {
+	mi := &file_traffic_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LinkStats.ProtoReflect.Descriptor instead.
+func (*LinkStats) Descriptor() ([]byte, []int) {
+	return file_traffic_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LinkStats) GetBegin() int64 {
+	if x != nil {
+		return x.Begin
+	}
+	return 0
+}
+
+func (x *LinkStats) GetDuration() int64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *LinkStats) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *LinkStats) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *LinkStats) GetFromLabel() string {
+	if x != nil {
+		return x.FromLabel
+	}
+	return ""
+}
+
+func (x *LinkStats) GetToLabel() string {
+	if x != nil {
+		return x.ToLabel
+	}
+	return ""
+}
+
+func (x *LinkStats) GetBackhaul() bool {
+	if x != nil {
+		return x.Backhaul
+	}
+	return false
+}
+
+func (x *LinkStats) GetBytes() int64 {
+	if x != nil {
+		return x.Bytes
+	}
+	return 0
+}
+
+func (x *LinkStats) GetPackets() int64 {
+	if x != nil {
+		return x.Packets
+	}
+	return 0
+}
+
 var File_traffic_proto protoreflect.FileDescriptor
 
 var file_traffic_proto_rawDesc = []byte{
 	0x0a, 0x0d, 0x74, 0x72, 0x61, 0x66, 0x66, 0x69, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
-	0x08, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x89, 0x01, 0x0a, 0x07, 0x54, 0x72,
+	0x08, 0x74, 0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0xcd, 0x01, 0x0a, 0x07, 0x54, 0x72,
 	0x61, 0x66, 0x66, 0x69, 0x63, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x18, 0x0a,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x44,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x44,
 	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x44,
 	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x49, 0x50, 0x41, 0x64, 0x64,
 	0x72, 0x65, 0x73, 0x73, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x49, 0x50, 0x41, 0x64,
 	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x28,
 	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x50,
 	0x61, 0x63, 0x6b, 0x65, 0x74, 0x73, 0x18, 0x32, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x50, 0x61,
-	0x63, 0x6b, 0x65, 0x74, 0x73, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62,
-	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x63, 0x6b, 0x65, 0x74, 0x73, 0x12, 0x1c, 0x0a, 0x09, 0x42, 0x79, 0x74, 0x65, 0x73, 0x53, 0x65,
+	0x6e, 0x74, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x42, 0x79, 0x74, 0x65, 0x73, 0x53,
+	0x65, 0x6e, 0x74, 0x12, 0x24, 0x0a, 0x0d, 0x42, 0x79, 0x74, 0x65, 0x73, 0x52, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x18, 0x46, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x42, 0x79, 0x74, 0x65,
+	0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x22, 0xe5, 0x01, 0x0a, 0x09, 0x4c, 0x69,
+	0x6e, 0x6b, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x42, 0x65, 0x67, 0x69, 0x6e, 0x12, 0x1a, 0x0a,
+	0x08, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x08, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x46, 0x72, 0x6f,
+	0x6d, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x0e, 0x0a,
+	0x02, 0x54, 0x6f, 0x18, 0x28, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x54, 0x6f, 0x12, 0x1c, 0x0a,
+	0x09, 0x46, 0x72, 0x6f, 0x6d, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x32, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x46, 0x72, 0x6f, 0x6d, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x18, 0x0a, 0x07, 0x54,
+	0x6f, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x54, 0x6f,
+	0x4c, 0x61, 0x62, 0x65, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x42, 0x61, 0x63, 0x6b, 0x68, 0x61, 0x75,
+	0x6c, 0x18, 0x46, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x42, 0x61, 0x63, 0x6b, 0x68, 0x61, 0x75,
+	0x6c, 0x12, 0x14, 0x0a, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x18, 0x50, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x05, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65,
+	0x74, 0x73, 0x18, 0x5a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x50, 0x61, 0x63, 0x6b, 0x65, 0x74,
+	0x73, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
@@ -129,9 +275,10 @@ func file_traffic_proto_rawDescGZIP() []byte {
 	return file_traffic_proto_rawDescData
 }
 
-var file_traffic_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_traffic_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
 var file_traffic_proto_goTypes = []interface{}{
-	(*Traffic)(nil), // 0: tutorial.Traffic
+	(*Traffic)(nil),   // 0: tutorial.Traffic
+	(*LinkStats)(nil), // 1: tutorial.LinkStats
 }
 var file_traffic_proto_depIdxs = []int32{
 	0, // [0:0] is the sub-list for method output_type
@@ -159,6 +306,18 @@ func file_traffic_proto_init() {
 				return nil
 			}
 		}
+		file_traffic_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LinkStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -166,7 +325,7 @@ func file_traffic_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_traffic_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   1,
+			NumMessages:   2,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,88 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// maxTalkers is the number of links shown on the top talkers page
+const maxTalkers = 25
+
+//go:embed talkers.template.html
+var talkersRaw []byte
+
+// parse the template just once, at program startup
+var talkersTemplate = template.Must(template.New("talkers").Funcs(template.FuncMap{
+	"bytes": func(n int64) string {
+		return humanize.Bytes(uint64(n))
+	},
+}).Parse(string(talkersRaw)))
+
+// topTalkers holds the busiest links from the most recent interval
+type topTalkers struct {
+	m        sync.Mutex
+	begin    time.Time
+	duration time.Duration
+	links    []*LinkStats
+}
+
+// update replaces the links shown on the page
+func (t *topTalkers) update(begin time.Time, duration time.Duration, links []*LinkStats) {
+	if len(links) > maxTalkers {
+		links = links[:maxTalkers]
+	}
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.begin = begin
+	t.duration = duration
+	t.links = links
+}
+
+// Payload for the template
+type talkersPayload struct {
+	Begin    time.Time
+	Duration time.Duration
+	Links    []*LinkStats
+}
+
+func (t *topTalkers) handleRoot(w http.ResponseWriter, r *http.Request) {
+	t.m.Lock()
+	payload := talkersPayload{
+		Begin:    t.begin,
+		Duration: t.duration,
+		Links:    t.links,
+	}
+	t.m.Unlock()
+
+	if payload.Begin.IsZero() {
+		fmt.Fprintln(w, "no traffic has been counted yet")
+		return
+	}
+
+	err := talkersTemplate.Execute(w, payload)
+	if err != nil {
+		msg := fmt.Sprintf("error executing template: %v", err)
+		log.Println(msg)
+		http.Error(w, msg, http.StatusInternalServerError)
+		return
+	}
+}
+
+func (t *topTalkers) runWebUI(port string) {
+	http.HandleFunc("/", t.handleRoot)
+
+	// start the http server
+	log.Println("listening on " + port)
+	err := http.ListenAndServe(port, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/monasticacademy/maple-network-tools/orbi"
+)
+
+type enableArgs struct{}
+
+type packetArgs struct{}
+
+type runArgs struct {
+	Commands []string `arg:"positional,required" help:"shell commands to run, e.g. \"cat /proc/uptime\""`
+}
+
+type satellitesArgs struct {
+	File string `help:"parse saved output of wlanconfig instead of connecting to the Orbi"`
+}
+
+type args struct {
+	Host     string        `help:"IP address of the Orbi router or satellite"`
+	MAC      string        `help:"MAC address of the Orbi's LAN interface"`
+	User     string        `help:"username for the Orbi admin page"`
+	Pass     string        `help:"password for the Orbi admin page" arg:"env:PASS"`
+	Enable   bool          `help:"enable telnet before connecting"`
+	Backhaul string        `help:"radio that satellites associate with"`
+	Timeout  time.Duration `help:"how long to wait for each command"`
+
+	EnableCmd  *enableArgs     `arg:"subcommand:enable" help:"send the packets that enable telnet"`
+	Packet     *packetArgs     `arg:"subcommand:packet" help:"write the packets that enable telnet to stdout"`
+	Run        *runArgs        `arg:"subcommand:run" help:"run shell commands on the Orbi"`
+	Satellites *satellitesArgs `arg:"subcommand:satellites" help:"print the satellites associated with the backhaul and their link rates"`
+}
+
+// dial enables telnet if requested and then logs in
+func dial(args *args) (*orbi.Client, error) {
+	if args.Enable {
+		err := orbi.EnableTelnet(args.Host, args.MAC, args.User, args.Pass)
+		if err != nil {
+			return nil, fmt.Errorf("error enabling telnet: %w", err)
+		}
+		// give telnetd a moment to start
+		time.Sleep(time.Second)
+	}
+
+	c, err := orbi.Dial(args.Host, args.User, args.Pass, args.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("error logging in to %s: %w", args.Host, err)
+	}
+	return c, nil
+}
+
+// printStations prints a table of stations
+func printStations(stations []orbi.Station) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tCHANNEL\tTX RATE\tRX RATE\tRSSI\tASSOCIATED\tMODE")
+	for _, st := range stations {
+		fmt.Fprintf(w, "%v\t%d\t%d Mbps\t%d Mbps\t%d\t%s\t%s\n",
+			st.Addr, st.Channel, st.TxRate, st.RxRate, st.RSSI, st.AssocTime, st.Mode)
+	}
+	return w.Flush()
+}
+
+func main() {
+	var args args
+	args.Host = "10.0.0.1"
+	args.User = "admin"
+	args.Backhaul = "ath2"
+	args.Timeout = 10 * time.Second
+	p := arg.MustParse(&args)
+
+	switch {
+	case args.EnableCmd != nil:
+		if args.MAC == "" {
+			p.Fail("--mac is required")
+		}
+		err := orbi.EnableTelnet(args.Host, args.MAC, args.User, args.Pass)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("sent telnetenable packets to %s", args.Host)
+
+	case args.Packet != nil:
+		if args.MAC == "" {
+			p.Fail("--mac is required")
+		}
+		// the same packets that enable sends, for checking against the
+		// python script
+		for _, pw := range []string{args.Pass, orbi.HashPassword(args.Pass)} {
+			packet, err := orbi.TelnetEnablePacket(args.MAC, args.User, pw)
+			if err != nil {
+				log.Fatal(err)
+			}
+			os.Stdout.Write(packet)
+		}
+
+	case args.Run != nil:
+		c, err := dial(&args)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer c.Close()
+
+		for _, cmd := range args.Run.Commands {
+			out, err := c.Run(cmd)
+			if err != nil {
+				log.Fatal(err)
+			}
+			fmt.Print(out)
+		}
+
+	case args.Satellites != nil:
+		var stations []orbi.Station
+		if args.Satellites.File != "" {
+			f, err := os.Open(args.Satellites.File)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer f.Close()
+
+			stations, err = orbi.ParseStations(f)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			c, err := dial(&args)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer c.Close()
+
+			stations, err = c.Stations(args.Backhaul)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		err := printStations(stations)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+	default:
+		p.Fail("you must specify enable, packet, run, or satellites")
+	}
+}
@@ -0,0 +1,82 @@
+package orbi
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// blowfish is the Blowfish cipher as used by Netgear's telnetenable. The key
+// schedule is standard but the data is read and written as little-endian
+// 32-bit words, because the original telnetenable.c never converted from the
+// byte order of the machine it ran on.
+type blowfish struct {
+	p [18]uint32
+	s [4][256]uint32
+}
+
+// newBlowfish computes the subkeys for a key. Only the first 72 bytes of the
+// key are used.
+func newBlowfish(key []byte) *blowfish {
+	c := &blowfish{p: piPArray, s: piSBoxes}
+
+	// XOR each element of the P array with the key, cycling through the key
+	// as many times as needed
+	var j int
+	for i := range c.p {
+		var k uint32
+		for n := 0; n < 4; n++ {
+			k = k<<8 | uint32(key[j])
+			j = (j + 1) % len(key)
+		}
+		c.p[i] ^= k
+	}
+
+	// replace the P array and then the S-boxes with the output of repeatedly
+	// encrypting an all-zero block
+	var l, r uint32
+	for i := 0; i < len(c.p); i += 2 {
+		l, r = c.encryptBlock(l, r)
+		c.p[i], c.p[i+1] = l, r
+	}
+	for b := range c.s {
+		for i := 0; i < len(c.s[b]); i += 2 {
+			l, r = c.encryptBlock(l, r)
+			c.s[b][i], c.s[b][i+1] = l, r
+		}
+	}
+	return c
+}
+
+// f is the Blowfish round function
+func (c *blowfish) f(x uint32) uint32 {
+	return ((c.s[0][x>>24] + c.s[1][x>>16&0xff]) ^ c.s[2][x>>8&0xff]) + c.s[3][x&0xff]
+}
+
+// encryptBlock encrypts one 64-bit block given as two 32-bit halves
+func (c *blowfish) encryptBlock(l, r uint32) (uint32, uint32) {
+	for i := 0; i < 16; i += 2 {
+		l ^= c.p[i]
+		r ^= c.f(l)
+		r ^= c.p[i+1]
+		l ^= c.f(r)
+	}
+	return r ^ c.p[17], l ^ c.p[16]
+}
+
+// encryptECB encrypts data in electronic codebook mode. The length of the data
+// must be a multiple of the block size, which is 8 bytes.
+func (c *blowfish) encryptECB(data []byte) ([]byte, error) {
+	if len(data)%8 != 0 {
+		return nil, fmt.Errorf("data is not a multiple of the block size in length (%d bytes)", len(data))
+	}
+
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += 8 {
+		l := binary.LittleEndian.Uint32(data[i:])
+		r := binary.LittleEndian.Uint32(data[i+4:])
+		l, r = c.encryptBlock(l, r)
+		binary.LittleEndian.PutUint32(out[i:], l)
+		binary.LittleEndian.PutUint32(out[i+4:], r)
+	}
+	return out, nil
+}
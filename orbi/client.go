@@ -0,0 +1,48 @@
+package orbi
+
+import (
+	"net"
+	"time"
+
+	"github.com/monasticacademy/maple-network-tools/telnetsession"
+)
+
+// Client runs shell commands on an Orbi over telnet
+type Client struct {
+	s *telnetsession.Session
+}
+
+// Dial connects to telnetd on an Orbi and logs in. The username and password
+// are only sent if the device asks for them. The timeout applies to connecting
+// and to waiting for each command.
+func Dial(host, username, password string, timeout time.Duration) (*Client, error) {
+	s, err := telnetsession.Dial(net.JoinHostPort(host, "23"), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Login(username, password)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	// set a prompt that cannot be confused with command output. The quotes
+	// stop the echo of this command from matching the prompt.
+	err = s.SetPrompt(`PS1='ORBI''-PROMPT# '`, "ORBI-PROMPT# ")
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	return &Client{s: s}, nil
+}
+
+// Close closes the telnet connection
+func (c *Client) Close() error {
+	return c.s.Close()
+}
+
+// Run runs a shell command and returns its output
+func (c *Client) Run(cmd string) (string, error) {
+	return c.s.Run(cmd)
+}
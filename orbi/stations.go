@@ -0,0 +1,114 @@
+package orbi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Station is a row from the station table that "wlanconfig <iface> list sta"
+// prints for one radio. On the backhaul radio, the stations are the
+// satellites.
+type Station struct {
+	Addr      net.HardwareAddr
+	AID       int    // association ID
+	Channel   int    // 802.11 channel number
+	TxRate    int    // Mbps, from the access point to the station
+	RxRate    int    // Mbps, from the station to the access point
+	RSSI      int    // dB above the noise floor
+	Idle      int    // seconds since the station was last active
+	AssocTime string // time since the station associated, as hh:mm:ss
+	Mode      string // such as IEEE80211_MODE_11AC_VHT80
+}
+
+// ParseStations parses the output of "wlanconfig <iface> list sta". The
+// numeric columns are found by name from the header line, since they differ
+// between firmware versions. Later columns such as CAPS can be blank or hold
+// several words, so the association time and mode are found by their format.
+// Lines that do not start with a MAC address are skipped.
+func ParseStations(r io.Reader) ([]Station, error) {
+	var columns map[string]int
+	var stations []Station
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "ADDR" {
+			columns = make(map[string]int)
+			for i, name := range fields {
+				columns[name] = i
+			}
+			continue
+		}
+
+		addr, err := net.ParseMAC(fields[0])
+		if err != nil || columns == nil {
+			continue
+		}
+
+		// field gets a column by name, or the empty string if it is missing
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(fields) {
+				return ""
+			}
+			return fields[i]
+		}
+
+		// number parses a column that holds an integer, possibly with a unit
+		// suffix such as the "M" in "1300M"
+		number := func(name string) (int, error) {
+			v := strings.TrimRight(field(name), "Mm")
+			if v == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, fmt.Errorf("error parsing %s for %v: %w", name, addr, err)
+			}
+			return n, nil
+		}
+
+		st := Station{Addr: addr}
+		for _, f := range fields {
+			if strings.HasPrefix(f, "IEEE80211_MODE_") {
+				st.Mode = f
+			} else if strings.Count(f, ":") == 2 && len(f) == len("00:00:00") {
+				st.AssocTime = f
+			}
+		}
+		for name, dest := range map[string]*int{
+			"AID":    &st.AID,
+			"CHAN":   &st.Channel,
+			"TXRATE": &st.TxRate,
+			"RXRATE": &st.RxRate,
+			"RSSI":   &st.RSSI,
+			"IDLE":   &st.Idle,
+		} {
+			*dest, err = number(name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		stations = append(stations, st)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// Stations gets the stations associated with a radio, such as ath2, which is
+// the 5 GHz backhaul on the RBR50
+func (c *Client) Stations(iface string) ([]Station, error) {
+	out, err := c.Run("wlanconfig " + iface + " list sta")
+	if err != nil {
+		return nil, err
+	}
+	return ParseStations(strings.NewReader(out))
+}
@@ -0,0 +1,93 @@
+// Package orbi enables telnet on Netgear Orbi routers and satellites, and runs
+// commands on them over telnet
+package orbi
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// the telnetenable packet consists of the MD5 of the cleartext followed by
+// the cleartext, which holds the MAC address, username and password in
+// fixed-size fields
+const (
+	macSize       = 0x10
+	usernameSize  = 0x10
+	passwordSize  = 0x50
+	cleartextSize = 0x70
+	payloadSize   = 0xb0
+)
+
+// the Blowfish key is this prefix followed by the password
+const keyPrefix = "AMBIT_TELNET_ENABLE+"
+
+// padTo copies s to a zero-padded buffer of the given size
+func padTo(s string, size int) []byte {
+	buf := make([]byte, size)
+	copy(buf, s)
+	return buf
+}
+
+// TelnetEnablePacket constructs the UDP packet that enables telnet on a
+// Netgear device. The MAC address is that of the device's LAN interface, with
+// or without separators.
+func TelnetEnablePacket(mac, username, password string) ([]byte, error) {
+	mac = strings.ToUpper(strings.NewReplacer(":", "", "-", "").Replace(mac))
+	if len(mac) >= macSize {
+		return nil, fmt.Errorf("MAC address must be shorter than %d characters: %q", macSize, mac)
+	}
+	if len(username) > usernameSize {
+		return nil, fmt.Errorf("username must be at most %d characters", usernameSize)
+	}
+	if len(password) > passwordSize {
+		return nil, fmt.Errorf("password must be at most %d characters", passwordSize)
+	}
+
+	var cleartext []byte
+	cleartext = append(cleartext, padTo(mac, macSize)...)
+	cleartext = append(cleartext, padTo(username, usernameSize)...)
+	cleartext = append(cleartext, padTo(password, passwordSize)...)
+	cleartext = append(cleartext, make([]byte, cleartextSize-len(cleartext))...)
+
+	sum := md5.Sum(cleartext)
+	payload := append(sum[:], cleartext...)
+	payload = append(payload, make([]byte, payloadSize-len(payload))...)
+
+	key := keyPrefix + password
+	if len(key) > 0x80 {
+		key = key[:0x80]
+	}
+	return newBlowfish([]byte(key)).encryptECB(payload)
+}
+
+// HashPassword gets the form of a password that newer firmware expects in the
+// telnetenable packet, which is the uppercase hex SHA-256 of the password
+func HashPassword(password string) string {
+	return strings.ToUpper(fmt.Sprintf("%x", sha256.Sum256([]byte(password))))
+}
+
+// EnableTelnet sends telnetenable packets to UDP port 23 on a device. Since
+// older firmware expects the plain password and newer firmware expects the
+// hashed password, a packet is sent for each.
+func EnableTelnet(host, mac, username, password string) error {
+	conn, err := net.Dial("udp4", net.JoinHostPort(host, "23"))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, pw := range []string{password, HashPassword(password)} {
+		packet, err := TelnetEnablePacket(mac, username, pw)
+		if err != nil {
+			return err
+		}
+		_, err = conn.Write(packet)
+		if err != nil {
+			return fmt.Errorf("error sending telnetenable packet: %w", err)
+		}
+	}
+	return nil
+}
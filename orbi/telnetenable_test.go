@@ -0,0 +1,81 @@
+package orbi
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// The packets in testdata were generated by genhash in
+// scripts/enable-orbi-telnet.py. Each file holds the packet with the plain
+// password followed by the packet with the hashed password, as sent by
+// EnableTelnet.
+func TestTelnetEnablePacket(t *testing.T) {
+	tests := []struct {
+		file     string
+		mac      string
+		username string
+		password string
+	}{
+		{"testdata/telnetenable.bin", "78:D2:94:9B:F3:2B", "admin", "password"},
+		{"testdata/telnetenable-nopassword.bin", "78D2949BF32B", "admin", ""},
+	}
+	for _, test := range tests {
+		want, err := os.ReadFile(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []byte
+		for _, pw := range []string{test.password, HashPassword(test.password)} {
+			packet, err := TelnetEnablePacket(test.mac, test.username, pw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(packet) != payloadSize {
+				t.Errorf("%s: expected a %d byte packet, got %d", test.file, payloadSize, len(packet))
+			}
+			got = append(got, packet...)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: packets do not match\ngot  %x\nwant %x", test.file, got, want)
+		}
+	}
+}
+
+func TestTelnetEnablePacketMACFormats(t *testing.T) {
+	a, err := TelnetEnablePacket("78:d2:94:9b:f3:2b", "admin", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := TelnetEnablePacket("78-D2-94-9B-F3-2B", "admin", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Error("expected the same packet for a MAC address with colons or dashes")
+	}
+}
+
+func TestTelnetEnablePacketErrors(t *testing.T) {
+	tests := []struct {
+		mac, username, password string
+	}{
+		{"78:D2:94:9B:F3:2B:00:11", "admin", "password"},
+		{"78D2949BF32B", "a-username-that-is-too-long", "password"},
+		{"78D2949BF32B", "admin", string(make([]byte, passwordSize+1))},
+	}
+	for _, test := range tests {
+		_, err := TelnetEnablePacket(test.mac, test.username, test.password)
+		if err == nil {
+			t.Errorf("expected an error for %q %q and a %d character password", test.mac, test.username, len(test.password))
+		}
+	}
+}
+
+func TestHashPassword(t *testing.T) {
+	const want = "5E884898DA28047151D0E56F8DC6292773603D0D6AABBDD62A11EF721D1542D8"
+	if got := HashPassword("password"); got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"log"
+	"sync"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// intervalStats holds all the statistics collected over one interval
+type intervalStats struct {
+	statsByLink map[link]*statistics
+	rfByStation map[string]*rfStats
+	mgmt        *mgmtStats
+	alerts      []alertEntry
+}
+
+func newIntervalStats() *intervalStats {
+	return &intervalStats{
+		statsByLink: make(map[link]*statistics),
+		rfByStation: make(map[string]*rfStats),
+		mgmt:        newMgmtStats(),
+	}
+}
+
+// collector accumulates statistics from the capture loop. The statistics are
+// swapped for empty ones when flushing, so that the capture loop only waits for
+// the swap and never for an upload.
+type collector struct {
+	mu      sync.Mutex
+	current *intervalStats
+	rogues  *rogueDetector // nil if there is no allowlist of access points
+}
+
+func newCollector(rogues *rogueDetector) *collector {
+	return &collector{
+		current: newIntervalStats(),
+		rogues:  rogues,
+	}
+}
+
+// add adds a captured packet to the statistics, and logs any alert that it
+// raises once the lock is released so that logging never holds up a swap
+func (c *collector) add(packet gopacket.Packet) {
+	wifiFrame, ok := packet.Layer(layers.LayerTypeDot11).(*layers.Dot11)
+	if !ok {
+		return
+	}
+
+	alert := c.count(packet, wifiFrame)
+	if alert != nil {
+		log.Printf("%s: %q from %s on channel %d", alert.Kind, alert.SSID, alert.BSSID, alert.Channel)
+	}
+}
+
+// count adds a frame to the statistics and returns the rogue access point
+// alert that it raised, if any
+func (c *collector) count(packet gopacket.Packet, wifiFrame *layers.Dot11) *alertEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	from := wifiFrame.Address1
+	to := wifiFrame.Address2
+	key := link{From: from.String(), To: to.String()}
+	stats := c.current.statsByLink[key]
+	if stats == nil {
+		stats = new(statistics)
+		c.current.statsByLink[key] = stats
+	}
+
+	stats.Frames += 1
+	stats.Bytes += len(wifiFrame.Payload)
+	var alert *alertEntry
+	switch wifiFrame.Type.MainType() {
+	case layers.Dot11TypeMgmt:
+		stats.MgmtFrames += 1
+		c.current.mgmt.add(packet, wifiFrame)
+		if c.rogues != nil {
+			alert = c.rogues.check(packet, wifiFrame)
+			if alert != nil {
+				c.current.alerts = append(c.current.alerts, *alert)
+			}
+		}
+	case layers.Dot11TypeCtrl:
+		stats.CtrlFrames += 1
+	case layers.Dot11TypeData:
+		stats.DataFrames += 1
+	}
+
+	// radio measurements are for the transmitter, which is not known for
+	// acknowledgements and other frames without a second address
+	if rt, ok := packet.Layer(layers.LayerTypeRadioTap).(*layers.RadioTap); ok && len(wifiFrame.Address2) > 0 {
+		if !rt.Flags.BadFCS() {
+			station := wifiFrame.Address2.String()
+			rf := c.current.rfByStation[station]
+			if rf == nil {
+				rf = new(rfStats)
+				c.current.rfByStation[station] = rf
+			}
+			rf.add(rt, wifiFrame)
+		}
+	}
+	return alert
+}
+
+// swap returns the statistics collected so far and starts collecting afresh
+func (c *collector) swap() *intervalStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.current
+	c.current = newIntervalStats()
+	return stats
+}
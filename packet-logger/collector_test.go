@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// dataPacket builds a data frame from the laptop to the access point
+func dataPacket(t *testing.T) gopacket.Packet {
+	t.Helper()
+	ap, _ := net.ParseMAC("9c:3d:cf:10:20:31")
+	laptop, _ := net.ParseMAC("78:45:58:ea:e9:26")
+	buf := gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{},
+		&layers.Dot11{Type: layers.Dot11TypeData, Flags: layers.Dot11FlagsToDS, Address1: ap, Address2: laptop, Address3: ap},
+		gopacket.Payload(make([]byte, 100)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the decoder expects a frame check sequence at the end
+	raw := append(buf.Bytes(), 0, 0, 0, 0)
+	return gopacket.NewPacket(raw, layers.LayerTypeDot11, gopacket.Default)
+}
+
+// frames gets the total number of frames in the statistics
+func frames(stats *intervalStats) int {
+	var n int
+	for _, s := range stats.statsByLink {
+		n += s.Frames
+	}
+	return n
+}
+
+func TestSwapWhileCounting(t *testing.T) {
+	const n = 5000
+	packet := dataPacket(t)
+	c := newCollector(nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < n; i++ {
+			c.add(packet)
+		}
+	}()
+
+	// swap as fast as possible while the frames are being counted, and check
+	// that every frame ends up in exactly one interval
+	var total, swaps int
+	for counting := true; counting; swaps++ {
+		select {
+		case <-done:
+			counting = false
+		default:
+		}
+		total += frames(c.swap())
+	}
+
+	if total != n {
+		t.Errorf("expected %d frames across %d swaps, got %d", n, swaps, total)
+	}
+}
+
+func TestCaptureUploadsLastInterval(t *testing.T) {
+	for _, stopBy := range []string{"cancel", "end of capture"} {
+		t.Run(stopBy, func(t *testing.T) {
+			packet := dataPacket(t)
+			c := newCollector(nil)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			// the interval is long enough that only the final upload happens
+			var mu sync.Mutex
+			var uploads []int
+			upload := func() {
+				mu.Lock()
+				defer mu.Unlock()
+				uploads = append(uploads, frames(c.swap()))
+			}
+
+			packets := make(chan gopacket.Packet)
+			returned := make(chan struct{})
+			go func() {
+				defer close(returned)
+				capture(ctx, packets, c, time.Hour, upload)
+			}()
+
+			// the channel is unbuffered, so each send waits for the capture loop
+			for i := 0; i < 3; i++ {
+				packets <- packet
+			}
+			if stopBy == "cancel" {
+				cancel()
+			} else {
+				close(packets)
+			}
+
+			select {
+			case <-returned:
+			case <-time.After(5 * time.Second):
+				t.Fatal("capture did not return")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(uploads) != 1 || uploads[0] != 3 {
+				t.Errorf("expected one final upload of 3 frames, got %v", uploads)
+			}
+		})
+	}
+}
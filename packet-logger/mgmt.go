@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// deauthEntry is the number of deauthentication or disassociation frames sent
+// from one address to another with a given reason, which we send to cloud
+// logging once per N seconds
+type deauthEntry struct {
+	Kind       string // "deauth" or "disassoc"
+	From       string
+	To         string
+	Reason     int
+	ReasonText string
+	Frames     int
+}
+
+// probeEntry is the number of probe requests sent by one client
+type probeEntry struct {
+	Client string
+	Frames int
+	SSIDs  []string // the networks the client asked for, other than wildcard probes
+}
+
+// beaconEntry is the number of beacons sent by one access point for one SSID
+type beaconEntry struct {
+	BSSID  string
+	SSID   string
+	Frames int
+}
+
+// deauthKey identifies the deauthentication or disassociation frames that are
+// counted together
+type deauthKey struct {
+	Kind   string
+	From   string
+	To     string
+	Reason layers.Dot11Reason
+}
+
+// beaconKey identifies the beacons that are counted together
+type beaconKey struct {
+	BSSID string
+	SSID  string
+}
+
+// probeStats accumulates the probe requests from one client
+type probeStats struct {
+	Frames int
+	SSIDs  map[string]bool
+}
+
+// mgmtStats accumulates statistics about management frames
+type mgmtStats struct {
+	deauths map[deauthKey]int
+	probes  map[string]*probeStats
+	beacons map[beaconKey]int
+}
+
+func newMgmtStats() *mgmtStats {
+	return &mgmtStats{
+		deauths: make(map[deauthKey]int),
+		probes:  make(map[string]*probeStats),
+		beacons: make(map[beaconKey]int),
+	}
+}
+
+// add adds a management frame to the statistics
+func (s *mgmtStats) add(packet gopacket.Packet, frame *layers.Dot11) {
+	switch frame.Type {
+	case layers.Dot11TypeMgmtDeauthentication:
+		if deauth, ok := packet.Layer(layers.LayerTypeDot11MgmtDeauthentication).(*layers.Dot11MgmtDeauthentication); ok {
+			s.deauths[deauthKey{"deauth", frame.Address2.String(), frame.Address1.String(), deauth.Reason}] += 1
+		}
+
+	case layers.Dot11TypeMgmtDisassociation:
+		if disassoc, ok := packet.Layer(layers.LayerTypeDot11MgmtDisassociation).(*layers.Dot11MgmtDisassociation); ok {
+			s.deauths[deauthKey{"disassoc", frame.Address2.String(), frame.Address1.String(), disassoc.Reason}] += 1
+		}
+
+	case layers.Dot11TypeMgmtProbeReq:
+		client := frame.Address2.String()
+		probes := s.probes[client]
+		if probes == nil {
+			probes = &probeStats{SSIDs: make(map[string]bool)}
+			s.probes[client] = probes
+		}
+		probes.Frames += 1
+		if ssid := frameSSID(packet); ssid != "" {
+			probes.SSIDs[ssid] = true
+		}
+
+	case layers.Dot11TypeMgmtBeacon:
+		s.beacons[beaconKey{frame.Address3.String(), frameSSID(packet)}] += 1
+	}
+}
+
+// deauthEntries converts the deauthentication and disassociation counts to
+// log entries
+func (s *mgmtStats) deauthEntries() []deauthEntry {
+	var entries []deauthEntry
+	for k, v := range s.deauths {
+		entries = append(entries, deauthEntry{
+			Kind:       k.Kind,
+			From:       k.From,
+			To:         k.To,
+			Reason:     int(k.Reason),
+			ReasonText: reasonText(k.Reason),
+			Frames:     v,
+		})
+	}
+	return entries
+}
+
+// probeEntries converts the probe request counts to log entries
+func (s *mgmtStats) probeEntries() []probeEntry {
+	var entries []probeEntry
+	for k, v := range s.probes {
+		entry := probeEntry{Client: k, Frames: v.Frames}
+		for ssid := range v.SSIDs {
+			entry.SSIDs = append(entry.SSIDs, ssid)
+		}
+		sort.Strings(entry.SSIDs)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// beaconEntries converts the beacon counts to log entries
+func (s *mgmtStats) beaconEntries() []beaconEntry {
+	var entries []beaconEntry
+	for k, v := range s.beacons {
+		entries = append(entries, beaconEntry{BSSID: k.BSSID, SSID: k.SSID, Frames: v})
+	}
+	return entries
+}
+
+// reasonText describes the common reason codes from IEEE 802.11-2016 table
+// 9-45. The names in gopacket are off by one.
+func reasonText(reason layers.Dot11Reason) string {
+	switch reason {
+	case 1:
+		return "unspecified"
+	case 2:
+		return "previous authentication no longer valid"
+	case 3:
+		return "station is leaving"
+	case 4:
+		return "inactivity"
+	case 5:
+		return "access point cannot handle all associated stations"
+	case 6:
+		return "class 2 frame from nonauthenticated station"
+	case 7:
+		return "class 3 frame from nonassociated station"
+	case 8:
+		return "station has left the BSS"
+	case 9:
+		return "station not authenticated"
+	case 15:
+		return "4-way handshake timeout"
+	case 16:
+		return "group key handshake timeout"
+	case 23:
+		return "802.1X authentication failed"
+	case 34:
+		return "excessive unacknowledged frames"
+	default:
+		return fmt.Sprintf("reason %d", reason)
+	}
+}
+
+// frameSSID gets the SSID information element of a management frame, or the
+// empty string for hidden networks and wildcard probe requests
+func frameSSID(packet gopacket.Packet) string {
+	for _, ie := range informationElements(packet) {
+		if ie.ID == layers.Dot11InformationElementIDSSID {
+			return string(ie.Info)
+		}
+	}
+	return ""
+}
+
+// informationElements gets the information elements of a beacon, probe
+// request or probe response. The elements are parsed from the frame body
+// because gopacket does not decode them for probe requests, and fails on
+// elements shorter than four bytes at the end of a frame.
+func informationElements(packet gopacket.Packet) []*layers.Dot11InformationElement {
+	// the elements follow the fixed fields, of which probe requests have none
+	var body []byte
+	if beacon, ok := packet.Layer(layers.LayerTypeDot11MgmtBeacon).(*layers.Dot11MgmtBeacon); ok {
+		body = beacon.Payload
+	} else if resp, ok := packet.Layer(layers.LayerTypeDot11MgmtProbeResp).(*layers.Dot11MgmtProbeResp); ok {
+		body = resp.Payload
+	} else if req, ok := packet.Layer(layers.LayerTypeDot11MgmtProbeReq).(*layers.Dot11MgmtProbeReq); ok {
+		body = req.Contents
+	}
+
+	var ies []*layers.Dot11InformationElement
+	for len(body) >= 2 {
+		n := int(body[1])
+		if len(body) < 2+n {
+			break
+		}
+		ies = append(ies, &layers.Dot11InformationElement{
+			ID:     layers.Dot11InformationElementID(body[0]),
+			Length: uint8(n),
+			Info:   body[2 : 2+n],
+		})
+		body = body[2+n:]
+	}
+	return ies
+}
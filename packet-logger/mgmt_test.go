@@ -0,0 +1,239 @@
+package main
+
+import (
+	"net"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// mgmtPacket serializes a management frame and decodes it again, as the
+// capture loop would see it
+func mgmtPacket(t *testing.T, typ layers.Dot11Type, from, to string, body ...gopacket.SerializableLayer) gopacket.Packet {
+	t.Helper()
+	src, err := net.ParseMAC(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := net.ParseMAC(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := gopacket.NewSerializeBuffer()
+	ls := append([]gopacket.SerializableLayer{&layers.Dot11{Type: typ, Address1: dst, Address2: src, Address3: src}}, body...)
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, ls...); err != nil {
+		t.Fatal(err)
+	}
+	// the decoder expects a frame check sequence at the end
+	raw := append(buf.Bytes(), 0, 0, 0, 0)
+	return gopacket.NewPacket(raw, layers.LayerTypeDot11, gopacket.Default)
+}
+
+// rawBody is part of a frame body that is serialized as is, for information
+// elements that gopacket would not write
+type rawBody []byte
+
+func (b rawBody) SerializeTo(buf gopacket.SerializeBuffer, opts gopacket.SerializeOptions) error {
+	out, err := buf.PrependBytes(len(b))
+	if err != nil {
+		return err
+	}
+	copy(out, b)
+	return nil
+}
+
+func (b rawBody) LayerType() gopacket.LayerType { return gopacket.LayerTypePayload }
+
+func ssidIE(ssid string) rawBody {
+	return append(rawBody{byte(layers.Dot11InformationElementIDSSID), byte(len(ssid))}, ssid...)
+}
+
+func addFrame(t *testing.T, s *mgmtStats, packet gopacket.Packet) {
+	t.Helper()
+	frame, ok := packet.Layer(layers.LayerTypeDot11).(*layers.Dot11)
+	if !ok {
+		t.Fatal("not an 802.11 frame")
+	}
+	s.add(packet, frame)
+}
+
+func TestReasonText(t *testing.T) {
+	cases := []struct {
+		reason layers.Dot11Reason
+		want   string
+	}{
+		{1, "unspecified"},
+		{3, "station is leaving"},
+		{7, "class 3 frame from nonassociated station"},
+		{8, "station has left the BSS"},
+		{15, "4-way handshake timeout"},
+		{34, "excessive unacknowledged frames"},
+		{0, "reason 0"},
+		{99, "reason 99"},
+	}
+	for _, c := range cases {
+		if got := reasonText(c.reason); got != c.want {
+			t.Errorf("reasonText(%d): got %q, want %q", c.reason, got, c.want)
+		}
+	}
+}
+
+func TestDeauthCounting(t *testing.T) {
+	const ap, laptop, phone = "9c:3d:cf:10:20:31", "78:45:58:ea:e9:26", "da:a1:19:6e:02:4c"
+	s := newMgmtStats()
+	deauth := &layers.Dot11MgmtDeauthentication{Reason: 7}
+	addFrame(t, s, mgmtPacket(t, layers.Dot11TypeMgmtDeauthentication, ap, laptop, deauth))
+	addFrame(t, s, mgmtPacket(t, layers.Dot11TypeMgmtDeauthentication, ap, laptop, deauth))
+	addFrame(t, s, mgmtPacket(t, layers.Dot11TypeMgmtDeauthentication, ap, laptop, &layers.Dot11MgmtDeauthentication{Reason: 3}))
+	addFrame(t, s, mgmtPacket(t, layers.Dot11TypeMgmtDisassociation, phone, ap, &layers.Dot11MgmtDisassociation{Reason: 8}))
+
+	got := s.deauthEntries()
+	sort.Slice(got, func(i, j int) bool { return got[i].Kind+got[i].ReasonText < got[j].Kind+got[j].ReasonText })
+	want := []deauthEntry{
+		{Kind: "deauth", From: ap, To: laptop, Reason: 7, ReasonText: "class 3 frame from nonassociated station", Frames: 2},
+		{Kind: "deauth", From: ap, To: laptop, Reason: 3, ReasonText: "station is leaving", Frames: 1},
+		{Kind: "disassoc", From: phone, To: ap, Reason: 8, ReasonText: "station has left the BSS", Frames: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestProbeAndBeaconCounting(t *testing.T) {
+	const ap, rogue, phone, stranger = "9c:3d:cf:10:20:31", "02:de:ad:be:ef:01", "da:a1:19:6e:02:4c", "12:34:56:78:9a:bc"
+	const broadcast = "ff:ff:ff:ff:ff:ff"
+	s := newMgmtStats()
+
+	probe := func(client, ssid string) gopacket.Packet {
+		return mgmtPacket(t, layers.Dot11TypeMgmtProbeReq, client, broadcast, ssidIE(ssid))
+	}
+	addFrame(t, s, probe(phone, "MAPLE"))
+	addFrame(t, s, probe(phone, "eduroam"))
+	addFrame(t, s, probe(phone, "MAPLE"))
+	addFrame(t, s, probe(stranger, "")) // wildcard probe
+
+	beacon := func(bssid, ssid string) gopacket.Packet {
+		return mgmtPacket(t, layers.Dot11TypeMgmtBeacon, bssid, broadcast, &layers.Dot11MgmtBeacon{Interval: 100}, ssidIE(ssid))
+	}
+	addFrame(t, s, beacon(ap, "MAPLE"))
+	addFrame(t, s, beacon(ap, "MAPLE"))
+	addFrame(t, s, beacon(rogue, "MAPLE"))
+	addFrame(t, s, beacon(ap, "")) // hidden network
+
+	probes := s.probeEntries()
+	sort.Slice(probes, func(i, j int) bool { return probes[i].Client < probes[j].Client })
+	wantProbes := []probeEntry{
+		{Client: stranger, Frames: 1},
+		{Client: phone, Frames: 3, SSIDs: []string{"MAPLE", "eduroam"}},
+	}
+	if !reflect.DeepEqual(probes, wantProbes) {
+		t.Errorf("got probes %+v\nwant %+v", probes, wantProbes)
+	}
+
+	beacons := s.beaconEntries()
+	sort.Slice(beacons, func(i, j int) bool {
+		return beacons[i].BSSID+beacons[i].SSID < beacons[j].BSSID+beacons[j].SSID
+	})
+	wantBeacons := []beaconEntry{
+		{BSSID: rogue, SSID: "MAPLE", Frames: 1},
+		{BSSID: ap, SSID: "", Frames: 1},
+		{BSSID: ap, SSID: "MAPLE", Frames: 2},
+	}
+	if !reflect.DeepEqual(beacons, wantBeacons) {
+		t.Errorf("got beacons %+v\nwant %+v", beacons, wantBeacons)
+	}
+}
+
+func TestInformationElements(t *testing.T) {
+	const ap, phone, broadcast = "9c:3d:cf:10:20:31", "da:a1:19:6e:02:4c", "ff:ff:ff:ff:ff:ff"
+	beaconFixed := &layers.Dot11MgmtBeacon{Interval: 100}
+
+	type ie struct {
+		id   layers.Dot11InformationElementID
+		info string
+	}
+	cases := []struct {
+		name   string
+		packet func() gopacket.Packet
+		want   []ie
+	}{
+		{
+			name: "beacon",
+			packet: func() gopacket.Packet {
+				return mgmtPacket(t, layers.Dot11TypeMgmtBeacon, ap, broadcast, beaconFixed,
+					ssidIE("MAPLE"), rawBody{byte(layers.Dot11InformationElementIDDSSet), 1, 6})
+			},
+			want: []ie{{layers.Dot11InformationElementIDSSID, "MAPLE"}, {layers.Dot11InformationElementIDDSSet, "\x06"}},
+		},
+		{
+			name: "probe request, which has no fixed fields",
+			packet: func() gopacket.Packet {
+				return mgmtPacket(t, layers.Dot11TypeMgmtProbeReq, phone, broadcast,
+					ssidIE("eduroam"), rawBody{byte(layers.Dot11InformationElementIDRates), 2, 0x82, 0x84})
+			},
+			want: []ie{{layers.Dot11InformationElementIDSSID, "eduroam"}, {layers.Dot11InformationElementIDRates, "\x82\x84"}},
+		},
+		{
+			name: "probe response",
+			packet: func() gopacket.Packet {
+				return mgmtPacket(t, layers.Dot11TypeMgmtProbeResp, ap, phone, &layers.Dot11MgmtProbeResp{Interval: 100},
+					ssidIE("MAPLE"))
+			},
+			want: []ie{{layers.Dot11InformationElementIDSSID, "MAPLE"}},
+		},
+		{
+			name: "short element at the end",
+			packet: func() gopacket.Packet {
+				return mgmtPacket(t, layers.Dot11TypeMgmtBeacon, ap, broadcast, beaconFixed,
+					ssidIE(""), rawBody{byte(layers.Dot11InformationElementIDDSSet), 1, 11})
+			},
+			want: []ie{{layers.Dot11InformationElementIDSSID, ""}, {layers.Dot11InformationElementIDDSSet, "\x0b"}},
+		},
+		{
+			name: "truncated element is dropped",
+			packet: func() gopacket.Packet {
+				return mgmtPacket(t, layers.Dot11TypeMgmtBeacon, ap, broadcast, beaconFixed,
+					ssidIE("MAPLE"), rawBody{byte(layers.Dot11InformationElementIDRSNInfo), 20, 1, 0})
+			},
+			want: []ie{{layers.Dot11InformationElementIDSSID, "MAPLE"}},
+		},
+		{
+			name: "frame without elements",
+			packet: func() gopacket.Packet {
+				return mgmtPacket(t, layers.Dot11TypeMgmtDeauthentication, ap, phone, &layers.Dot11MgmtDeauthentication{Reason: 3})
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got []ie
+			for _, e := range informationElements(c.packet()) {
+				if int(e.Length) != len(e.Info) {
+					t.Errorf("element %d has length %d but %d bytes", e.ID, e.Length, len(e.Info))
+				}
+				got = append(got, ie{e.ID, string(e.Info)})
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestFrameSSID(t *testing.T) {
+	packet := mgmtPacket(t, layers.Dot11TypeMgmtBeacon, "9c:3d:cf:10:20:31", "ff:ff:ff:ff:ff:ff",
+		&layers.Dot11MgmtBeacon{Interval: 100}, rawBody{byte(layers.Dot11InformationElementIDDSSet), 1, 6}, ssidIE("MAPLE"))
+	if got := frameSSID(packet); got != "MAPLE" {
+		t.Errorf("expected the SSID after another element, got %q", got)
+	}
+
+	packet = mgmtPacket(t, layers.Dot11TypeMgmtBeacon, "9c:3d:cf:10:20:31", "ff:ff:ff:ff:ff:ff",
+		&layers.Dot11MgmtBeacon{Interval: 100}, rawBody{0})
+	if got := frameSSID(packet); got != "" {
+		t.Errorf("expected no SSID, got %q", got)
+	}
+}
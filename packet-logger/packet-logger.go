@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	_ "embed"
-	"fmt"
+	"encoding/json"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"cloud.google.com/go/logging"
@@ -14,6 +16,7 @@ import (
 	"github.com/google/gopacket/layers"
 	"github.com/google/gopacket/pcapgo"
 	"github.com/mdlayher/wifi"
+	"github.com/monasticacademy/maple-network-tools/pcapfile"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 )
@@ -21,44 +24,15 @@ import (
 //go:embed secrets/service-account.json
 var googleCredentials []byte
 
-func listStations() {
-	wificlient, err := wifi.New()
-	if err != nil {
-		fmt.Printf("error creating wifi client: %v\n", err)
-		os.Exit(1)
-	}
-
-	ifs, err := wificlient.Interfaces()
-	if err != nil {
-		fmt.Printf("error listing interfaces: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Printf("found %d interfaces\n", len(ifs))
-
-	for _, iface := range ifs {
-		fmt.Println(iface.Name)
-		infos, err := wificlient.StationInfo(iface)
-		if err != nil {
-			fmt.Println("error getting station info:g", err)
-			continue
-		}
-
-		fmt.Printf("  got %d info structs\n", len(infos))
-
-		for _, info := range infos {
-			fmt.Printf("  %d\n", info.Signal)
-		}
-
-	}
-}
-
 // logEntry is the data that send to cloud logging once per N seconds
 type logEntry struct {
-	From   string
-	To     string
-	Bytes  int
-	Frames int
+	From       string
+	To         string
+	Bytes      int
+	Frames     int
+	MgmtFrames int
+	CtrlFrames int
+	DataFrames int
 }
 
 // link represents a from and to address
@@ -67,100 +41,261 @@ type link struct {
 	To   string
 }
 
+// captureEntry is the number of packets that the socket received and dropped
+// since the previous entry, which we send to cloud logging once per N seconds
+type captureEntry struct {
+	Interface string
+	Packets   int
+	Drops     int
+}
+
 // statistics represents the statistics we keep for each from/to address
 type statistics struct {
-	Frames int // number of wifi frames observed
-	Bytes  int // number of bytes in all observed wifi frames
+	Frames     int // number of wifi frames observed
+	Bytes      int // number of bytes in all observed wifi frames
+	MgmtFrames int // number of management frames
+	CtrlFrames int // number of control frames
+	DataFrames int // number of data frames
 }
 
 func main() {
-	ctx := context.Background()
+	// cancel on SIGINT or SIGTERM so that pending stats are flushed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	var args struct {
-		Interface string `arg:"positional,required"`
-		LogName   string
-		Interval  time.Duration
+		Interface      string `arg:"positional"`
+		LogName        string
+		Interval       time.Duration
+		PcapFile       string `arg:"--pcap-file" help:"read packets from a pcap or pcapng file and print the log entries instead of uploading them"`
+		RFLogName      string `help:"log for per-station signal strength, data rate and retries from radiotap headers"`
+		Stations       bool   `help:"also poll nl80211 for the stations associated with local interfaces"`
+		StationLogName string `help:"log for the stations from nl80211"`
+		CaptureLogName string `help:"log for the number of packets received and dropped by the capture socket"`
+		MgmtLogName    string `help:"log for deauthentication, disassociation, probe request and beacon counts"`
+		AccessPoints   string `help:"JSON file mapping each of our SSIDs to the BSSIDs of our access points, for detecting rogue access points"`
+		AlertLogName   string `help:"log for rogue access point alerts"`
 	}
 	args.LogName = "wifi-packets"
+	args.RFLogName = "wifi-rf"
+	args.StationLogName = "wifi-stations"
+	args.CaptureLogName = "wifi-capture"
+	args.MgmtLogName = "wifi-mgmt"
+	args.AlertLogName = "wifi-alerts"
 	args.Interval = 10 * time.Minute
-	arg.MustParse(&args)
+	p := arg.MustParse(&args)
 
-	// unpack google credentials
-	creds, err := google.CredentialsFromJSON(ctx, googleCredentials)
-	if err != nil {
-		log.Fatal("error parsing credentials: ", err)
+	if args.Interface == "" && args.PcapFile == "" {
+		p.Fail("interface is required")
 	}
 
-	log.Println("project:", creds.ProjectID)
-	log.Println("log interval:", args.Interval)
+	var pkgsrc *gopacket.PacketSource
+	var handle *pcapgo.EthernetHandle
+	var emitter func(logName string) func(payload interface{})
+	if args.PcapFile != "" {
+		// open capture file and print log entries as JSON
+		f, src, err := pcapfile.Open(args.PcapFile)
+		if err != nil {
+			log.Fatal("error opening capture file: ", err)
+		}
+		defer f.Close()
+		pkgsrc = src
 
-	// create the logger
-	logClient, err := logging.NewClient(ctx, creds.ProjectID,
-		option.WithCredentialsJSON(googleCredentials))
-	if err != nil {
-		log.Fatal("error creating logging client: ", err)
-	}
-	defer logClient.Close()
+		enc := json.NewEncoder(os.Stdout)
+		emitter = func(logName string) func(interface{}) {
+			return func(payload interface{}) {
+				if err := enc.Encode(payload); err != nil {
+					log.Fatal(err)
+				}
+			}
+		}
+	} else {
+		// unpack google credentials
+		creds, err := google.CredentialsFromJSON(ctx, googleCredentials)
+		if err != nil {
+			log.Fatal("error parsing credentials: ", err)
+		}
+
+		log.Println("project:", creds.ProjectID)
+		log.Println("log interval:", args.Interval)
 
-	lg := logClient.Logger(args.LogName)
+		// create the logger, which is closed last so that the final flush is sent
+		logClient, err := logging.NewClient(context.Background(), creds.ProjectID,
+			option.WithCredentialsJSON(googleCredentials))
+		if err != nil {
+			log.Fatal("error creating logging client: ", err)
+		}
+		defer logClient.Close()
+
+		emitter = func(logName string) func(interface{}) {
+			lg := logClient.Logger(logName)
+			return func(payload interface{}) {
+				lg.Log(logging.Entry{Payload: payload})
+			}
+		}
 
-	// open packet capture handle
-	handle, err := pcapgo.NewEthernetHandle(args.Interface)
-	if err != nil {
-		log.Fatal("error creating ethernet handle: ", err)
+		// open packet capture handle
+		handle, err = pcapgo.NewEthernetHandle(args.Interface)
+		if err != nil {
+			log.Fatal("error creating ethernet handle: ", err)
+		}
+		defer handle.Close()
+		pkgsrc = gopacket.NewPacketSource(handle, layers.LayerTypeDot11)
 	}
 
-	lastUpload := time.Now()
-	statsByLink := make(map[link]*statistics)
+	emit := emitter(args.LogName)
+	emitRF := emitter(args.RFLogName)
+	emitStation := emitter(args.StationLogName)
+	emitCapture := emitter(args.CaptureLogName)
+	emitMgmt := emitter(args.MgmtLogName)
+	emitAlert := emitter(args.AlertLogName)
 
-	pkgsrc := gopacket.NewPacketSource(handle, layers.LayerTypeDot11)
-	for packet := range pkgsrc.Packets() {
-		lay := packet.Layer(layers.LayerTypeDot11)
-		if lay == nil {
-			continue
+	// the stations are polled from nl80211 each time we upload
+	var wificlient *wifi.Client
+	if args.Stations {
+		var err error
+		wificlient, err = wifi.New()
+		if err != nil {
+			log.Fatal("error creating wifi client: ", err)
+		}
+		defer wificlient.Close()
+	}
+
+	// rogue access points are detected by comparing beacons to the allowlist
+	var rogues *rogueDetector
+	if args.AccessPoints != "" {
+		aps, err := loadAccessPoints(args.AccessPoints)
+		if err != nil {
+			log.Fatal("error loading access points: ", err)
 		}
+		rogues = newRogueDetector(aps)
+		log.Printf("loaded access points for %d SSIDs", len(aps))
+	}
+
+	c := newCollector(rogues)
 
-		wifiFrame, ok := lay.(*layers.Dot11)
-		if !ok {
-			continue
+	upload := func() {
+		stats := c.swap()
+		for k, v := range stats.statsByLink {
+			emit(logEntry{
+				From:       k.From,
+				To:         k.To,
+				Frames:     v.Frames,
+				Bytes:      v.Bytes,
+				MgmtFrames: v.MgmtFrames,
+				CtrlFrames: v.CtrlFrames,
+				DataFrames: v.DataFrames,
+			})
+		}
+		for k, v := range stats.rfByStation {
+			emitRF(v.entry(k))
 		}
 
-		//fmt.Println(packet)
-		//continue
+		var mgmtEntries int
+		for _, entry := range stats.mgmt.deauthEntries() {
+			emitMgmt(entry)
+			mgmtEntries++
+		}
+		for _, entry := range stats.mgmt.probeEntries() {
+			emitMgmt(entry)
+			mgmtEntries++
+		}
+		for _, entry := range stats.mgmt.beaconEntries() {
+			emitMgmt(entry)
+			mgmtEntries++
+		}
 
-		from := wifiFrame.Address1
-		to := wifiFrame.Address2
-		key := link{From: from.String(), To: to.String()}
-		stats := statsByLink[key]
-		if stats == nil {
-			stats = new(statistics)
-			statsByLink[key] = stats
+		for _, alert := range stats.alerts {
+			emitAlert(alert)
 		}
 
-		stats.Frames += 1
-		stats.Bytes += len(wifiFrame.Payload)
+		log.Printf("uploaded %d log entries, %d rf entries, %d management entries and %d alerts\n",
+			len(stats.statsByLink), len(stats.rfByStation), mgmtEntries, len(stats.alerts))
 
-		now := time.Now()
-		if now.Sub(lastUpload) > args.Interval {
-			for k, v := range statsByLink {
-				lg.Log(logging.Entry{
-					Payload: logEntry{
-						From:   k.From,
-						To:     k.To,
-						Frames: v.Frames,
-						Bytes:  v.Bytes,
-					},
+		// the socket resets its counters each time they are read
+		if handle != nil {
+			stats, err := handle.Stats()
+			if err != nil {
+				log.Println("error getting capture stats: ", err)
+			} else {
+				if stats.Drops > 0 {
+					log.Printf("capture socket dropped %d of %d packets", stats.Drops, stats.Packets)
+				}
+				emitCapture(captureEntry{
+					Interface: args.Interface,
+					Packets:   int(stats.Packets),
+					Drops:     int(stats.Drops),
 				})
 			}
+		}
+
+		if wificlient != nil {
+			stations, err := pollStations(wificlient)
+			if err != nil {
+				log.Println("error polling stations: ", err)
+			}
+			for _, station := range stations {
+				emitStation(station)
+			}
+		}
+	}
+
+	// aggregate capture files over capture time rather than wall clock time
+	if args.PcapFile != "" {
+		err := pcapfile.Replay(pkgsrc, args.Interval, c.add, func(begin, end time.Time) error {
+			upload()
+			return nil
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	capture(ctx, pkgsrc.Packets(), c, args.Interval, upload)
+}
 
-			log.Printf("uploaded %d log entries\n", len(statsByLink))
+// capture adds packets to the collector until the context is cancelled or the
+// packets run out. It calls upload on a ticker in the background so that stats
+// are flushed even when the channel is quiet, and so that the capture loop never
+// waits on an upload, and once more for the last partial interval before
+// returning.
+func capture(ctx context.Context, packets <-chan gopacket.Packet, c *collector, interval time.Duration, upload func()) {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
 
-			// TODO: delete the entries one-by-one to reduce memory churn
-			statsByLink = make(map[link]*statistics)
-			lastUpload = now
+	uploaded := make(chan struct{})
+	go func() {
+		defer close(uploaded)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				upload()
+			case <-ctx.Done():
+				upload()
+				return
+			}
 		}
+	}()
 
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("shutting down")
+			break loop
+		case packet, ok := <-packets:
+			if !ok {
+				log.Println("capture ended")
+				break loop
+			}
+			c.add(packet)
+		}
 	}
 
-	_ = ctx
+	// wait for the final upload
+	stop()
+	<-uploaded
 }
@@ -0,0 +1,68 @@
+//go:generate go run ../pcapfile/gencaptures packet-logger
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/monasticacademy/maple-network-tools/pcapfile"
+)
+
+// replayFile replays a capture in 10 second intervals and returns the
+// statistics for each interval
+func replayFile(t *testing.T, path string) []*intervalStats {
+	t.Helper()
+	aps, err := loadAccessPoints("testdata/access-points.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, src, err := pcapfile.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	c := newCollector(newRogueDetector(aps))
+	var intervals []*intervalStats
+	err = pcapfile.Replay(src, 10*time.Second, c.add, func(begin, end time.Time) error {
+		intervals = append(intervals, c.swap())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return intervals
+}
+
+// The two captures in testdata hold the same synthetic frames with radiotap
+// headers, one in pcap format and one in pcapng format
+func TestReplayPcapAndPcapng(t *testing.T) {
+	pcap := replayFile(t, "testdata/capture.pcap")
+	pcapng := replayFile(t, "testdata/capture.pcapng")
+
+	if len(pcap) != 3 {
+		t.Fatalf("expected 3 intervals, got %d", len(pcap))
+	}
+	if !reflect.DeepEqual(pcap, pcapng) {
+		t.Error("expected the same statistics from the pcap and pcapng captures")
+	}
+
+	// the first interval has a beacon with our SSID from an unknown BSSID, and
+	// an open network on our channel
+	var kinds []string
+	for _, alert := range pcap[0].alerts {
+		kinds = append(kinds, alert.Kind+" "+alert.BSSID)
+	}
+	want := []string{"unknown-bssid 02:de:ad:be:ef:01", "open-network 0e:ca:fe:00:00:01"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("got alerts %q, want %q", kinds, want)
+	}
+
+	// radio measurements come from the radiotap headers in both formats
+	rf := pcap[0].rfByStation["78:45:58:ea:e9:26"]
+	if rf == nil || rf.entry("78:45:58:ea:e9:26").Frames == 0 {
+		t.Error("expected radio measurements for the laptop")
+	}
+}
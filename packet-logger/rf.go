@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+
+	"github.com/google/gopacket/layers"
+)
+
+// rfEntry is the radio quality data that we send to cloud logging for each
+// transmitting station once per N seconds
+type rfEntry struct {
+	Station    string
+	Channel    int     // 802.11 channel number, or 0 if unknown
+	Frames     int     // number of frames sent by the station
+	RetryRatio float64 // fraction of frames with the retry flag set
+
+	// the signal fields are left out if no frame had a signal measurement
+	MinSignal *int     `json:",omitempty"` // dBm
+	AvgSignal *float64 `json:",omitempty"` // dBm
+	MaxSignal *int     `json:",omitempty"` // dBm
+
+	AvgRate float64 // Mbps, for frames sent at legacy rates
+}
+
+// rfStats accumulates the radiotap measurements for frames from one station
+type rfStats struct {
+	Frames    int
+	Retries   int
+	Signals   int // number of frames with a signal measurement
+	SignalSum int
+	MinSignal int
+	MaxSignal int
+	Rates     int // number of frames with a data rate
+	RateSum   float64
+	Channel   int
+}
+
+// add adds a frame and its radiotap header to the statistics
+func (s *rfStats) add(rt *layers.RadioTap, frame *layers.Dot11) {
+	s.Frames += 1
+	if frame.Flags.Retry() {
+		s.Retries += 1
+	}
+	if rt.Present.DBMAntennaSignal() {
+		signal := int(rt.DBMAntennaSignal)
+		if s.Signals == 0 || signal < s.MinSignal {
+			s.MinSignal = signal
+		}
+		if s.Signals == 0 || signal > s.MaxSignal {
+			s.MaxSignal = signal
+		}
+		s.Signals += 1
+		s.SignalSum += signal
+	}
+	if rt.Present.Rate() {
+		s.Rates += 1
+		s.RateSum += float64(rt.Rate) / 2 // radiotap rates are in units of 500 kbps
+	}
+	if rt.Present.Channel() {
+		s.Channel = channelNumber(int(rt.ChannelFrequency))
+	}
+}
+
+// entry converts the statistics to a log entry
+func (s *rfStats) entry(station string) rfEntry {
+	e := rfEntry{
+		Station: station,
+		Channel: s.Channel,
+		Frames:  s.Frames,
+	}
+	if s.Frames > 0 {
+		e.RetryRatio = float64(s.Retries) / float64(s.Frames)
+	}
+	if s.Signals > 0 {
+		min, max := s.MinSignal, s.MaxSignal
+		avg := math.Round(10*float64(s.SignalSum)/float64(s.Signals)) / 10
+		e.MinSignal, e.AvgSignal, e.MaxSignal = &min, &avg, &max
+	}
+	if s.Rates > 0 {
+		e.AvgRate = math.Round(10*s.RateSum/float64(s.Rates)) / 10
+	}
+	return e
+}
+
+// channelNumber converts a frequency in MHz to an 802.11 channel number
+func channelNumber(freq int) int {
+	switch {
+	case freq == 2484:
+		return 14
+	case freq >= 2412 && freq < 2484:
+		return (freq - 2407) / 5
+	case freq >= 5955 && freq <= 7115:
+		return (freq - 5950) / 5
+	case freq >= 5000 && freq < 5955:
+		return (freq - 5000) / 5
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket/layers"
+)
+
+// rfFrame is the radiotap measurements of one frame from a station
+type rfFrame struct {
+	signal int8 // dBm, or 0 if the radiotap header has no signal
+	rate   int  // Mbps, or 0 if the radiotap header has no rate
+	freq   int  // MHz, or 0 if the radiotap header has no channel
+	retry  bool
+}
+
+func (f rfFrame) layers() (*layers.RadioTap, *layers.Dot11) {
+	rt := &layers.RadioTap{}
+	if f.signal != 0 {
+		rt.Present |= layers.RadioTapPresentDBMAntennaSignal
+		rt.DBMAntennaSignal = f.signal
+	}
+	if f.rate != 0 {
+		rt.Present |= layers.RadioTapPresentRate
+		rt.Rate = layers.RadioTapRate(f.rate * 2)
+	}
+	if f.freq != 0 {
+		rt.Present |= layers.RadioTapPresentChannel
+		rt.ChannelFrequency = layers.RadioTapChannelFrequency(f.freq)
+	}
+	frame := &layers.Dot11{Type: layers.Dot11TypeData}
+	if f.retry {
+		frame.Flags |= layers.Dot11FlagsRetry
+	}
+	return rt, frame
+}
+
+func intPtr(v int) *int           { return &v }
+func floatPtr(v float64) *float64 { return &v }
+
+func TestRFEntry(t *testing.T) {
+	cases := []struct {
+		name   string
+		frames []rfFrame
+		want   rfEntry
+	}{
+		{
+			name:   "signal range and average",
+			frames: []rfFrame{{signal: -61, rate: 54, freq: 2437}, {signal: -58, rate: 54}, {signal: -70, rate: 24}},
+			want: rfEntry{Channel: 6, Frames: 3, MinSignal: intPtr(-70), AvgSignal: floatPtr(-63), MaxSignal: intPtr(-58),
+				AvgRate: 44},
+		},
+		{
+			name:   "average is rounded to one decimal place",
+			frames: []rfFrame{{signal: -60}, {signal: -61}, {signal: -61}},
+			want:   rfEntry{Frames: 3, MinSignal: intPtr(-61), AvgSignal: floatPtr(-60.7), MaxSignal: intPtr(-60)},
+		},
+		{
+			name:   "retry ratio",
+			frames: []rfFrame{{retry: true}, {}, {retry: true}, {}},
+			want:   rfEntry{Frames: 4, RetryRatio: 0.5},
+		},
+		{
+			name:   "frames without a signal are left out of the signal fields",
+			frames: []rfFrame{{signal: -45, freq: 5180}, {rate: 6}},
+			want:   rfEntry{Channel: 36, Frames: 2, MinSignal: intPtr(-45), AvgSignal: floatPtr(-45), MaxSignal: intPtr(-45), AvgRate: 6},
+		},
+		{
+			name:   "no signal at all",
+			frames: []rfFrame{{rate: 6}, {rate: 12, retry: true}},
+			want:   rfEntry{Frames: 2, RetryRatio: 0.5, AvgRate: 9},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var s rfStats
+			for _, f := range c.frames {
+				s.add(f.layers())
+			}
+			c.want.Station = "78:45:58:ea:e9:26"
+
+			got, err := json.Marshal(s.entry(c.want.Station))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := json.Marshal(c.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("got %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRFEntryWithoutSignalOmitsSignalFields(t *testing.T) {
+	var s rfStats
+	s.add(rfFrame{rate: 6}.layers())
+	buf, err := json.Marshal(s.entry("78:45:58:ea:e9:26"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "Signal") {
+		t.Errorf("expected no signal fields, got %s", buf)
+	}
+}
+
+func TestChannelNumber(t *testing.T) {
+	cases := []struct {
+		freq int
+		want int
+	}{
+		{2412, 1},
+		{2437, 6},
+		{2472, 13},
+		{2484, 14},
+		{5180, 36},
+		{5825, 165},
+		{5955, 1},  // 6 GHz channel 1
+		{6115, 33}, // 6 GHz
+		{7115, 233},
+		{900, 0},
+		{0, 0},
+	}
+	for _, c := range cases {
+		if got := channelNumber(c.freq); got != c.want {
+			t.Errorf("channelNumber(%d): got %d, want %d", c.freq, got, c.want)
+		}
+	}
+}
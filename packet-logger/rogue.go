@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// alertEntry is raised when a beacon or probe response looks like it comes
+// from a rogue access point
+type alertEntry struct {
+	Kind    string // "unknown-bssid" or "open-network"
+	SSID    string
+	BSSID   string
+	Channel int // 802.11 channel number, or 0 if unknown
+	Signal  int // dBm, or 0 if unknown
+	Time    time.Time
+}
+
+// accessPoints is the allowlist of BSSIDs for each of our SSIDs. It is loaded
+// from a JSON file that maps each SSID to its BSSIDs, and each BSSID to a
+// description such as "orbi satellite in main hall".
+type accessPoints map[string]map[string]string
+
+// loadAccessPoints reads the allowlist of access points from a JSON file
+func loadAccessPoints(path string) (accessPoints, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw accessPoints
+	err = json.Unmarshal(buf, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	// normalize the BSSIDs so that they match the frames
+	aps := make(accessPoints)
+	for ssid, bssids := range raw {
+		aps[ssid] = make(map[string]string)
+		for bssid, desc := range bssids {
+			hw, err := net.ParseMAC(bssid)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing BSSID for %s: %w", ssid, err)
+			}
+			aps[ssid][hw.String()] = desc
+		}
+	}
+	return aps, nil
+}
+
+// alertInterval is how long an alert is suppressed after it is raised, so that
+// an access point that is still around is reported again once in a while
+const alertInterval = 6 * time.Hour
+
+// alertKey identifies alerts that are only raised once per alertInterval
+type alertKey struct {
+	Kind  string
+	SSID  string
+	BSSID string
+}
+
+// rogueDetector raises alerts when our SSID is advertised by an access point
+// that is not on the allowlist, or when an open network appears on one of the
+// channels that our access points use
+type rogueDetector struct {
+	known    accessPoints
+	channels map[int]bool           // channels that our access points have been seen on
+	alerted  map[alertKey]time.Time // time at which each alert was last raised
+}
+
+func newRogueDetector(known accessPoints) *rogueDetector {
+	return &rogueDetector{
+		known:    known,
+		channels: make(map[int]bool),
+		alerted:  make(map[alertKey]time.Time),
+	}
+}
+
+// check looks at beacons and probe responses and returns an alert when an
+// access point looks suspicious and has not been alerted on within
+// alertInterval, or nil otherwise
+func (d *rogueDetector) check(packet gopacket.Packet, frame *layers.Dot11) *alertEntry {
+	var capabilities uint16
+	switch frame.Type {
+	case layers.Dot11TypeMgmtBeacon:
+		beacon, ok := packet.Layer(layers.LayerTypeDot11MgmtBeacon).(*layers.Dot11MgmtBeacon)
+		if !ok {
+			return nil
+		}
+		capabilities = beacon.Flags
+	case layers.Dot11TypeMgmtProbeResp:
+		resp, ok := packet.Layer(layers.LayerTypeDot11MgmtProbeResp).(*layers.Dot11MgmtProbeResp)
+		if !ok {
+			return nil
+		}
+		capabilities = resp.Flags
+	default:
+		return nil
+	}
+
+	bssid := frame.Address3.String()
+	alert := alertEntry{
+		BSSID: bssid,
+		Time:  packet.Metadata().Timestamp,
+	}
+
+	// the privacy bit is set by all networks that use WEP, WPA or WPA2
+	open := capabilities&0x0010 == 0
+	for _, ie := range informationElements(packet) {
+		switch ie.ID {
+		case layers.Dot11InformationElementIDSSID:
+			alert.SSID = string(ie.Info)
+		case layers.Dot11InformationElementIDDSSet:
+			if len(ie.Info) == 1 {
+				alert.Channel = int(ie.Info[0])
+			}
+		case layers.Dot11InformationElementIDRSNInfo:
+			open = false
+		}
+	}
+	if rt, ok := packet.Layer(layers.LayerTypeRadioTap).(*layers.RadioTap); ok {
+		if alert.Channel == 0 && rt.Present.Channel() {
+			alert.Channel = channelNumber(int(rt.ChannelFrequency))
+		}
+		if rt.Present.DBMAntennaSignal() {
+			alert.Signal = int(rt.DBMAntennaSignal)
+		}
+	}
+
+	bssids, ours := d.known[alert.SSID]
+	if _, known := bssids[bssid]; known {
+		if alert.Channel != 0 {
+			d.channels[alert.Channel] = true
+		}
+		return nil
+	}
+
+	switch {
+	case ours:
+		alert.Kind = "unknown-bssid"
+	case open && d.channels[alert.Channel]:
+		alert.Kind = "open-network"
+	default:
+		return nil
+	}
+
+	key := alertKey{alert.Kind, alert.SSID, alert.BSSID}
+	if last, ok := d.alerted[key]; ok && alert.Time.Sub(last) < alertInterval {
+		return nil
+	}
+	d.expire(alert.Time)
+	d.alerted[key] = alert.Time
+	return &alert
+}
+
+// expire forgets the alerts that were raised more than alertInterval before now,
+// so that access points that have gone away do not stay in memory
+func (d *rogueDetector) expire(now time.Time) {
+	for key, last := range d.alerted {
+		if now.Sub(last) >= alertInterval {
+			delete(d.alerted, key)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+// beaconPacket builds a beacon for a WPA2 network with the given SSID, sent at
+// the given time
+func beaconPacket(t *testing.T, bssid, ssid string, at time.Time) gopacket.Packet {
+	t.Helper()
+	hw, err := net.ParseMAC(bssid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := gopacket.NewSerializeBuffer()
+	err = gopacket.SerializeLayers(buf, gopacket.SerializeOptions{},
+		&layers.Dot11{Type: layers.Dot11TypeMgmtBeacon, Address1: layers.EthernetBroadcast, Address2: hw, Address3: hw},
+		&layers.Dot11MgmtBeacon{Interval: 100, Flags: 0x0011},
+		&layers.Dot11InformationElement{ID: layers.Dot11InformationElementIDSSID, Length: uint8(len(ssid)), Info: []byte(ssid)},
+		&layers.Dot11InformationElement{ID: layers.Dot11InformationElementIDDSSet, Length: 1, Info: []byte{6}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the decoder expects a frame check sequence at the end
+	raw := append(buf.Bytes(), 0, 0, 0, 0)
+	packet := gopacket.NewPacket(raw, layers.LayerTypeDot11, gopacket.Default)
+	packet.Metadata().Timestamp = at
+	return packet
+}
+
+func TestRogueAlertsExpire(t *testing.T) {
+	d := newRogueDetector(accessPoints{"MAPLE": {"9c:3d:cf:10:20:31": "orbi router"}})
+	check := func(at time.Time) *alertEntry {
+		packet := beaconPacket(t, "02:de:ad:be:ef:01", "MAPLE", at)
+		return d.check(packet, packet.Layer(layers.LayerTypeDot11).(*layers.Dot11))
+	}
+
+	now := time.Date(2026, 10, 2, 9, 30, 0, 0, time.UTC)
+	if alert := check(now); alert == nil || alert.Kind != "unknown-bssid" {
+		t.Fatalf("expected an unknown-bssid alert, got %v", alert)
+	}
+	if alert := check(now.Add(time.Minute)); alert != nil {
+		t.Fatalf("expected the alert to be suppressed, got %v", alert)
+	}
+	if alert := check(now.Add(alertInterval)); alert == nil {
+		t.Fatal("expected the alert to be raised again after the alert interval")
+	}
+
+	// alerts for access points that have gone away are forgotten
+	d.expire(now.Add(3 * alertInterval))
+	if len(d.alerted) != 0 {
+		t.Errorf("expected expired alerts to be forgotten, got %v", d.alerted)
+	}
+}
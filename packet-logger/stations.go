@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mdlayher/wifi"
+)
+
+// stationEntry is the nl80211 view of a station associated with one of our
+// interfaces, which we send to cloud logging once per N seconds
+type stationEntry struct {
+	Interface       string
+	Station         string
+	Signal          int     // dBm
+	ReceiveBitrate  float64 // Mbps
+	TransmitBitrate float64 // Mbps
+	TransmitRetries int     // cumulative since the station connected
+	TransmitFailed  int     // cumulative since the station connected
+	BeaconLoss      int     // cumulative since the station connected
+	Connected       float64 // seconds since the station connected
+	Inactive        float64 // seconds since the station was last active
+}
+
+// pollStations gets the stations associated with each wifi interface
+func pollStations(c *wifi.Client) ([]stationEntry, error) {
+	ifs, err := c.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("error listing interfaces: %w", err)
+	}
+
+	var entries []stationEntry
+	for _, iface := range ifs {
+		// interfaces in monitor mode have no stations
+		if iface.Type != wifi.InterfaceTypeAP && iface.Type != wifi.InterfaceTypeStation {
+			continue
+		}
+
+		infos, err := c.StationInfo(iface)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // no stations associated
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error getting station info for %s: %w", iface.Name, err)
+		}
+
+		for _, info := range infos {
+			entries = append(entries, stationEntry{
+				Interface:       iface.Name,
+				Station:         info.HardwareAddr.String(),
+				Signal:          info.Signal,
+				ReceiveBitrate:  float64(info.ReceiveBitrate) / 1e6,
+				TransmitBitrate: float64(info.TransmitBitrate) / 1e6,
+				TransmitRetries: info.TransmitRetries,
+				TransmitFailed:  info.TransmitFailed,
+				BeaconLoss:      info.BeaconLoss,
+				Connected:       info.Connected.Seconds(),
+				Inactive:        info.Inactive.Seconds(),
+			})
+		}
+	}
+	return entries, nil
+}
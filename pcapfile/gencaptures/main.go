@@ -0,0 +1,282 @@
+// Command gencaptures writes the synthetic captures that the monitors replay in
+// their tests. Run it with go generate in orbi-monitor or packet-logger, which
+// writes capture.pcap and capture.pcapng to the testdata directory there.
+package main
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/alexflint/go-arg"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// the devices on the synthetic network
+var (
+	ap       = mac("9c:3d:cf:10:20:31") // orbi router
+	sat      = mac("9c:3d:cf:10:20:41") // orbi satellite
+	gw       = mac("48:8f:5a:3c:11:02") // gateway behind the router
+	laptop   = mac("78:45:58:ea:e9:26")
+	phone    = mac("da:a1:19:6e:02:4c")
+	tv       = mac("b4:22:00:50:0a:7f") // wired behind the satellite
+	stranger = mac("12:34:56:78:9a:bc") // not in the ARP table
+	rogue    = mac("02:de:ad:be:ef:01") // beacons our SSID but is not ours
+	cafe     = mac("0e:ca:fe:00:00:01") // open network on our channel
+)
+
+// rsn is an RSN information element for WPA2 with CCMP and PSK
+var rsn = []byte{1, 0, 0x00, 0x0f, 0xac, 4, 1, 0, 0x00, 0x0f, 0xac, 4, 1, 0, 0x00, 0x0f, 0xac, 2, 0, 0}
+
+// frame is one synthetic 802.11 frame and the radio measurements for it
+type frame struct {
+	offset time.Duration // since the start of the capture
+	dot11  *layers.Dot11
+	body   []gopacket.SerializableLayer
+	signal int8 // dBm
+	rate   int  // Mb/s
+	retry  bool
+}
+
+func mac(s string) net.HardwareAddr {
+	m, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func ie(id layers.Dot11InformationElementID, info []byte) gopacket.SerializableLayer {
+	return &layers.Dot11InformationElement{ID: id, Length: uint8(len(info)), Info: info}
+}
+
+func payload(n int) []gopacket.SerializableLayer {
+	return []gopacket.SerializableLayer{gopacket.Payload(make([]byte, n))}
+}
+
+func data(flags layers.Dot11Flags, a1, a2, a3, a4 net.HardwareAddr) *layers.Dot11 {
+	return &layers.Dot11{Type: layers.Dot11TypeData, Flags: flags, Address1: a1, Address2: a2, Address3: a3, Address4: a4}
+}
+
+func beacon(offset time.Duration, bssid net.HardwareAddr, ssid string, secure bool, signal int8) frame {
+	flags := uint16(0x0001) // ESS
+	if secure {
+		flags |= 0x0010 // privacy
+	}
+	body := []gopacket.SerializableLayer{
+		&layers.Dot11MgmtBeacon{Interval: 100, Flags: flags},
+		ie(layers.Dot11InformationElementIDSSID, []byte(ssid)),
+		ie(layers.Dot11InformationElementIDRates, []byte{0x82, 0x84, 0x8b, 0x96}),
+		ie(layers.Dot11InformationElementIDDSSet, []byte{6}),
+	}
+	if secure {
+		body = append(body, ie(layers.Dot11InformationElementIDRSNInfo, rsn))
+	}
+	dot11 := &layers.Dot11{Type: layers.Dot11TypeMgmtBeacon, Address1: layers.EthernetBroadcast, Address2: bssid, Address3: bssid}
+	return frame{offset, dot11, body, signal, 6, false}
+}
+
+func probe(offset time.Duration, client net.HardwareAddr, ssid string, signal int8) frame {
+	dot11 := &layers.Dot11{Type: layers.Dot11TypeMgmtProbeReq, Address1: layers.EthernetBroadcast, Address2: client, Address3: layers.EthernetBroadcast}
+	body := []gopacket.SerializableLayer{
+		ie(layers.Dot11InformationElementIDSSID, []byte(ssid)),
+		ie(layers.Dot11InformationElementIDRates, []byte{0x82, 0x84}),
+	}
+	return frame{offset, dot11, body, signal, 1, false}
+}
+
+func deauth(offset time.Duration, from, to net.HardwareAddr, reason layers.Dot11Reason, retry bool) frame {
+	dot11 := &layers.Dot11{Type: layers.Dot11TypeMgmtDeauthentication, Address1: to, Address2: from, Address3: from}
+	body := []gopacket.SerializableLayer{&layers.Dot11MgmtDeauthentication{Reason: reason}}
+	return frame{offset, dot11, body, -42, 6, retry}
+}
+
+// trafficFrames are data frames between stations on the network, for
+// orbi-monitor to account by IP address
+func trafficFrames() []frame {
+	return []frame{
+		beacon(0, ap, "MAPLE", true, -40),
+		{1 * time.Second, data(layers.Dot11FlagsToDS, ap, laptop, gw, nil), payload(1200), -61, 54, false},
+		{2 * time.Second, data(layers.Dot11FlagsFromDS, laptop, ap, gw, nil), payload(1500), -58, 54, false},
+		{3 * time.Second, data(layers.Dot11FlagsFromDS, laptop, ap, gw, nil), payload(1500), -59, 48, true},
+		{4 * time.Second, data(layers.Dot11FlagsToDS, ap, phone, gw, nil), payload(300), -72, 24, false},
+		{5 * time.Second, data(layers.Dot11FlagsToDS, ap, stranger, gw, nil), payload(700), -81, 12, false},
+		{12 * time.Second, data(layers.Dot11FlagsToDS|layers.Dot11FlagsFromDS, ap, sat, gw, tv), payload(900), -45, 54, false},
+		{13 * time.Second, data(layers.Dot11FlagsToDS|layers.Dot11FlagsFromDS, sat, ap, tv, gw), payload(1400), -44, 54, false},
+		{14 * time.Second, data(layers.Dot11FlagsToDS, ap, laptop, phone, nil), payload(100), -62, 36, false},
+		{15 * time.Second, &layers.Dot11{Type: layers.Dot11TypeCtrlAck, Address1: laptop}, nil, -60, 24, false},
+		{26 * time.Second, data(layers.Dot11FlagsFromDS, phone, ap, gw, nil), payload(800), -74, 18, true},
+	}
+}
+
+// managementFrames are beacons, probe requests and deauthentications, for
+// packet-logger to break down and to check for rogue access points
+func managementFrames() []frame {
+	disassoc := &layers.Dot11{Type: layers.Dot11TypeMgmtDisassociation, Address1: ap, Address2: phone, Address3: ap}
+	return []frame{
+		beacon(100*time.Millisecond, sat, "MAPLE", true, -48),
+		probe(3500*time.Millisecond, phone, "MAPLE", -70),
+		probe(3600*time.Millisecond, phone, "eduroam", -70),
+		probe(5500*time.Millisecond, stranger, "", -83),
+		beacon(7*time.Second, rogue, "MAPLE", true, -66),
+		beacon(8*time.Second, cafe, "Free Cafe WiFi", false, -77),
+		beacon(10200*time.Millisecond, ap, "MAPLE", true, -41),
+		deauth(11*time.Second, ap, laptop, layers.Dot11Reason(7), false),
+		deauth(11100*time.Millisecond, ap, laptop, layers.Dot11Reason(7), true),
+		{21 * time.Second, disassoc, []gopacket.SerializableLayer{&layers.Dot11MgmtDisassociation{Reason: layers.Dot11Reason(8)}}, -73, 6, false},
+	}
+}
+
+// capture describes the captures written for one monitor
+type capture struct {
+	start        time.Time
+	frames       []frame
+	pcapRadiotap bool // whether the pcap file has radiotap headers; the pcapng file always does
+}
+
+var captures = map[string]func() capture{
+	// the pcap file has bare 802.11 frames, to check that replay decodes by link type
+	"orbi-monitor": func() capture {
+		return capture{
+			start:  time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+			frames: trafficFrames(),
+		}
+	},
+	"packet-logger": func() capture {
+		frames := append(trafficFrames(), managementFrames()...)
+		sort.SliceStable(frames, func(i, j int) bool { return frames[i].offset < frames[j].offset })
+		return capture{
+			start:        time.Date(2026, 10, 2, 9, 30, 0, 0, time.UTC),
+			frames:       frames,
+			pcapRadiotap: true,
+		}
+	},
+}
+
+// serialize encodes a frame with a frame check sequence, or behind a radiotap
+// header
+func serialize(f frame, radiotap bool) ([]byte, error) {
+	if f.retry {
+		f.dot11.Flags |= layers.Dot11FlagsRetry
+	}
+	ls := append([]gopacket.SerializableLayer{f.dot11}, f.body...)
+
+	if radiotap {
+		rt := &layers.RadioTap{
+			Present:          layers.RadioTapPresentFlags | layers.RadioTapPresentRate | layers.RadioTapPresentChannel | layers.RadioTapPresentDBMAntennaSignal,
+			Rate:             layers.RadioTapRate(f.rate * 2),
+			ChannelFrequency: 2437,
+			ChannelFlags:     layers.RadioTapChannelFlagsGhz2 | layers.RadioTapChannelFlagsOFDM,
+			DBMAntennaSignal: f.signal,
+		}
+		buf := gopacket.NewSerializeBuffer()
+		err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, append([]gopacket.SerializableLayer{rt}, ls...)...)
+		return buf.Bytes(), err
+	}
+
+	buf := gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{}, ls...)
+	if err != nil {
+		return nil, err
+	}
+	raw := append([]byte{}, buf.Bytes()...)
+	fcs := make([]byte, 4)
+	binary.LittleEndian.PutUint32(fcs, crc32.ChecksumIEEE(raw))
+	return append(raw, fcs...), nil
+}
+
+// packetWriter is implemented by both the pcap and pcapng writers
+type packetWriter interface {
+	WritePacket(ci gopacket.CaptureInfo, data []byte) error
+}
+
+func write(w packetWriter, c capture, radiotap bool) error {
+	for _, f := range c.frames {
+		buf, err := serialize(f, radiotap)
+		if err != nil {
+			return err
+		}
+		ci := gopacket.CaptureInfo{Timestamp: c.start.Add(f.offset), CaptureLength: len(buf), Length: len(buf)}
+		err = w.WritePacket(ci, buf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writePcap(path string, c capture) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	linkType := layers.LinkTypeIEEE802_11
+	if c.pcapRadiotap {
+		linkType = layers.LinkTypeIEEE80211Radio
+	}
+	w := pcapgo.NewWriter(f)
+	err = w.WriteFileHeader(65536, linkType)
+	if err != nil {
+		return err
+	}
+	err = write(w, c, c.pcapRadiotap)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writePcapng(path string, c capture) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := pcapgo.NewNgWriter(f, layers.LinkTypeIEEE80211Radio)
+	if err != nil {
+		return err
+	}
+	err = write(w, c, true)
+	if err != nil {
+		return err
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func main() {
+	var args struct {
+		Monitor string `arg:"positional,required" help:"orbi-monitor or packet-logger"`
+		Out     string `help:"directory to write the captures to"`
+	}
+	args.Out = "testdata"
+	p := arg.MustParse(&args)
+
+	// the frames are built afresh for each file since serializing sets the retry flag
+	build, ok := captures[args.Monitor]
+	if !ok {
+		p.Fail("unknown monitor " + args.Monitor)
+	}
+
+	err := writePcap(filepath.Join(args.Out, "capture.pcap"), build())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = writePcapng(filepath.Join(args.Out, "capture.pcapng"), build())
+	if err != nil {
+		log.Fatal(err)
+	}
+}
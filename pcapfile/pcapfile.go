@@ -0,0 +1,83 @@
+// Package pcapfile reads packet captures in pcap or pcapng format so that the
+// monitors can replay them offline instead of capturing live
+package pcapfile
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// ngMagic is the block type of the section header that starts a pcapng file
+var ngMagic = []byte{0x0a, 0x0d, 0x0d, 0x0a}
+
+// reader is implemented by both the pcap and pcapng readers
+type reader interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+}
+
+// Open opens a capture file in pcap or pcapng format. Packets are decoded
+// according to the link type recorded in the file, so captures with or without
+// radiotap headers both work. The caller must close the returned file once
+// done with the packet source.
+func Open(path string) (io.Closer, *gopacket.PacketSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(len(ngMagic))
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	var r reader
+	if bytes.Equal(magic, ngMagic) {
+		r, err = pcapgo.NewNgReader(br, pcapgo.DefaultNgReaderOptions)
+	} else {
+		r, err = pcapgo.NewReader(br)
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	return f, gopacket.NewPacketSource(r, r.LinkType()), nil
+}
+
+// Replay passes each packet to add and groups the packets into windows of
+// capture time, rather than wall clock time. Windows start at multiples of the
+// interval, and flush is called with the start and end of each window that
+// holds any packets, once a packet from a later window arrives or the packets
+// run out. The capture may stop part way through the last window, so that
+// window ends at the last packet.
+func Replay(src *gopacket.PacketSource, interval time.Duration, add func(gopacket.Packet), flush func(begin, end time.Time) error) error {
+	var begin, last time.Time
+	for packet := range src.Packets() {
+		ts := packet.Metadata().Timestamp.UTC()
+		last = ts
+		if begin.IsZero() {
+			begin = ts.Truncate(interval)
+		}
+		if ts.Sub(begin) >= interval {
+			if err := flush(begin, begin.Add(interval)); err != nil {
+				return err
+			}
+			begin = ts.Truncate(interval)
+		}
+		add(packet)
+	}
+	if begin.IsZero() {
+		return nil
+	}
+	return flush(begin, last)
+}
@@ -0,0 +1,122 @@
+package pcapfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+var start = time.Date(2026, 10, 1, 12, 0, 3, 0, time.UTC)
+
+// writeCapture writes a packet at each offset from start, in pcap or pcapng
+// format, and returns the path
+func writeCapture(t *testing.T, ng bool, offsets ...time.Duration) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "capture")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var write func(gopacket.CaptureInfo, []byte) error
+	if ng {
+		w, err := pcapgo.NewNgWriter(f, layers.LinkTypeIEEE802_11)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer w.Flush()
+		write = w.WritePacket
+	} else {
+		w := pcapgo.NewWriter(f)
+		if err := w.WriteFileHeader(65536, layers.LinkTypeIEEE802_11); err != nil {
+			t.Fatal(err)
+		}
+		write = w.WritePacket
+	}
+
+	data := make([]byte, 24)
+	for _, offset := range offsets {
+		ci := gopacket.CaptureInfo{Timestamp: start.Add(offset), CaptureLength: len(data), Length: len(data)}
+		if err := write(ci, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+type window struct {
+	begin, end time.Time
+	packets    int
+}
+
+func replayWindows(t *testing.T, path string) []window {
+	t.Helper()
+	f, src, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var windows []window
+	var packets int
+	err = Replay(src, 10*time.Second, func(gopacket.Packet) {
+		packets++
+	}, func(begin, end time.Time) error {
+		windows = append(windows, window{begin, end, packets})
+		packets = 0
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return windows
+}
+
+func TestReplayWindows(t *testing.T) {
+	base := start.Truncate(10 * time.Second)
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+
+	// windows start at multiples of the interval, the empty window from 20s
+	// to 30s is skipped, and the last window ends at the last packet
+	want := []window{
+		{at(0), at(10), 3},
+		{at(10), at(20), 1},
+		{at(30), at(39), 2},
+	}
+
+	for _, ng := range []bool{false, true} {
+		path := writeCapture(t, ng, 0, 4*time.Second, 6999*time.Millisecond, 7*time.Second, 27*time.Second, 36*time.Second)
+		got := replayWindows(t, path)
+		if len(got) != len(want) {
+			t.Fatalf("pcapng=%v: expected %d windows, got %v", ng, len(want), got)
+		}
+		for i := range want {
+			if !got[i].begin.Equal(want[i].begin) || !got[i].end.Equal(want[i].end) || got[i].packets != want[i].packets {
+				t.Errorf("pcapng=%v: window %d: got %v, want %v", ng, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestReplayEmptyCapture(t *testing.T) {
+	got := replayWindows(t, writeCapture(t, false))
+	if len(got) != 0 {
+		t.Errorf("expected no windows, got %v", got)
+	}
+}
+
+func TestOpenNotACapture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("not a capture file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Open(path); err == nil {
+		t.Error("expected an error opening a file that is not a capture")
+	}
+}
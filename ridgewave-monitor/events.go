@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// connectedState is the connection state that the modem reports while the LTE
+// link is up
+const connectedState = "Connected"
+
+// maxQueuedPosts is the number of messages to hold while the webhook is slow,
+// beyond which new messages are dropped
+const maxQueuedPosts = 100
+
+// thresholds are the signal levels below which the LTE link counts as degraded
+type thresholds struct {
+	MinRSRP int64   // dBm
+	MinSINR float64 // dB
+}
+
+// eventDetector compares each reading from the modem with the previous one and
+// reports handovers, degradations, recoveries, disconnects and reconnects.
+// Handovers are only reported between two readings taken while connected.
+// Degradations are only reported when the signal first crosses the threshold,
+// and recoveries when it first comes back above it. Disconnects come from the
+// connection state that the modem reports, while failures to read the modem
+// over telnet are reported separately, since the LTE link may still be up.
+type eventDetector struct {
+	thresholds       thresholds
+	last             *LTESignal // most recent reading, or nil unless the modem was connected for it
+	lowRSRP          bool
+	lowSINR          bool
+	downSince        time.Time // zero unless the modem reports that it is disconnected
+	unreachableSince time.Time // zero unless the modem could not be read
+}
+
+// observe processes a reading from the modem and returns any events
+func (d *eventDetector) observe(row *LTESignal) []*LTEEvent {
+	var events []*LTEEvent
+	event := func(kind, format string, args ...interface{}) {
+		events = append(events, &LTEEvent{
+			Timestamp: row.Timestamp,
+			Kind:      kind,
+			Detail:    fmt.Sprintf(format, args...),
+			PCI:       row.PCI,
+			CID:       row.CID,
+			RSRP:      row.RSRP,
+			SINR:      row.SINR,
+		})
+	}
+
+	ts := time.UnixMicro(row.Timestamp)
+	if !d.unreachableSince.IsZero() {
+		event("telnet-recovered", "modem could be read again after %v", ts.Sub(d.unreachableSince).Round(time.Second))
+		d.unreachableSince = time.Time{}
+	}
+
+	// an empty state means that it could not be parsed, so leave it as it was
+	connected := strings.EqualFold(row.State, connectedState)
+	if row.State != "" && !connected && d.downSince.IsZero() {
+		event("disconnect", "modem connection state is %s", row.State)
+		d.downSince = ts
+	} else if connected && !d.downSince.IsZero() {
+		event("reconnect", "modem reconnected after %v", ts.Sub(d.downSince).Round(time.Second))
+		d.downSince = time.Time{}
+	}
+
+	// while the modem is disconnected the cell and signal are stale or
+	// missing, so they are not checked, and no handover is reported across
+	// the outage
+	if !d.downSince.IsZero() {
+		d.last = nil
+		return events
+	}
+
+	if d.last != nil && (d.last.PCI != row.PCI || d.last.CID != row.CID) {
+		event("handover", "cell changed from PCI %d CID %s to PCI %d CID %s",
+			d.last.PCI, d.last.CID, row.PCI, row.CID)
+	}
+
+	if row.RSRP < d.thresholds.MinRSRP && !d.lowRSRP {
+		event("low-rsrp", "RSRP dropped to %d dBm, below %d dBm", row.RSRP, d.thresholds.MinRSRP)
+		d.lowRSRP = true
+	} else if row.RSRP >= d.thresholds.MinRSRP && d.lowRSRP {
+		event("rsrp-recovered", "RSRP recovered to %d dBm", row.RSRP)
+		d.lowRSRP = false
+	}
+
+	if row.SINR < d.thresholds.MinSINR && !d.lowSINR {
+		event("low-sinr", "SINR dropped to %.1f dB, below %.1f dB", row.SINR, d.thresholds.MinSINR)
+		d.lowSINR = true
+	} else if row.SINR >= d.thresholds.MinSINR && d.lowSINR {
+		event("sinr-recovered", "SINR recovered to %.1f dB", row.SINR)
+		d.lowSINR = false
+	}
+
+	d.last = row
+	return events
+}
+
+// failed processes a failure to read from the modem and returns a
+// telnet-failure event the first time that the modem cannot be read
+func (d *eventDetector) failed(ts time.Time, err error) []*LTEEvent {
+	if !d.unreachableSince.IsZero() {
+		return nil
+	}
+	d.unreachableSince = ts
+	return []*LTEEvent{{
+		Timestamp: ts.UnixMicro(),
+		Kind:      "telnet-failure",
+		Detail:    fmt.Sprintf("could not read LTE info from modem: %v", err),
+	}}
+}
+
+// webhook posts messages to a slack-compatible incoming webhook in the
+// background, so that a slow webhook does not hold up polling the modem
+type webhook struct {
+	url    string
+	client http.Client
+	queue  chan string // messages waiting to be posted
+}
+
+// newWebhook starts posting messages to the given webhook
+func newWebhook(url string) *webhook {
+	w := webhook{
+		url:    url,
+		client: http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan string, maxQueuedPosts),
+	}
+	go w.run()
+	return &w
+}
+
+// post queues a message without waiting for it to be sent. Messages are
+// dropped if the queue is full.
+func (w *webhook) post(text string) {
+	select {
+	case w.queue <- text:
+	default:
+		log.Printf("webhook queue is full, dropping %q", text)
+	}
+}
+
+// run posts queued messages in order
+func (w *webhook) run() {
+	for text := range w.queue {
+		err := w.send(text)
+		if err != nil {
+			log.Println("error posting to webhook:", err)
+		}
+	}
+}
+
+// send posts a message to the webhook
+func (w *webhook) send(text string) error {
+	buf, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
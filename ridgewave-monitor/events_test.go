@@ -0,0 +1,90 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEventDetector(t *testing.T) {
+	start := time.Date(2026, 10, 2, 9, 30, 0, 0, time.UTC)
+	reading := func(minute int, state string, pci int64, rsrp int64, sinr float64) *LTESignal {
+		return &LTESignal{
+			Timestamp: start.Add(time.Duration(minute) * time.Minute).UnixMicro(),
+			State:     state,
+			PCI:       pci,
+			CID:       "A2F03",
+			RSRP:      rsrp,
+			SINR:      sinr,
+		}
+	}
+
+	d := eventDetector{thresholds: thresholds{MinRSRP: -110, MinSINR: 0}}
+	steps := []struct {
+		row  *LTESignal // nil for a failure to read the modem
+		want []string
+	}{
+		{reading(0, "Connected", 343, -91, 30), nil},
+		{reading(1, "Connected", 344, -91, 30), []string{"handover"}},
+		{reading(2, "Connected", 344, -115, -2), []string{"low-rsrp", "low-sinr"}},
+		{reading(3, "Connected", 344, -116, -3), nil},
+		{reading(4, "Searching", 344, -116, -3), []string{"disconnect"}},
+		{reading(5, "Searching", 0, 0, 0), nil}, // no measurements while searching
+		{nil, []string{"telnet-failure"}},
+		{nil, nil},
+		{reading(8, "", 344, -116, -3), []string{"telnet-recovered"}},
+		{reading(9, "connected", 345, -91, 30), []string{"reconnect", "rsrp-recovered", "sinr-recovered"}},
+		{reading(10, "Connected", 345, -91, 30), nil},
+		{reading(11, "Connected", 346, -91, 30), []string{"handover"}},
+	}
+	for i, step := range steps {
+		var events []*LTEEvent
+		if step.row == nil {
+			events = d.failed(start.Add(time.Duration(i)*time.Minute), errors.New("connection refused"))
+		} else {
+			events = d.observe(step.row)
+		}
+
+		var kinds []string
+		for _, e := range events {
+			kinds = append(kinds, e.Kind)
+		}
+		if !reflect.DeepEqual(kinds, step.want) {
+			t.Errorf("step %d: expected %v, got %v", i, step.want, kinds)
+		}
+	}
+}
+
+func TestWebhookDoesNotBlock(t *testing.T) {
+	received := make(chan struct{}, 2*maxQueuedPosts)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	// the first post holds up the webhook, so the rest are queued or dropped
+	w := newWebhook(server.URL)
+	start := time.Now()
+	for i := 0; i < 2*maxQueuedPosts; i++ {
+		w.post("LTE backup disconnect")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected posting to return immediately, took %v", elapsed)
+	}
+
+	// the queued posts are sent once the webhook responds
+	close(release)
+	timeout := time.After(5 * time.Second)
+	for n := 0; n < maxQueuedPosts || len(w.queue) > 0; n++ {
+		select {
+		case <-received:
+		case <-timeout:
+			t.Fatalf("expected the webhook to receive the queued posts, got %d", n)
+		}
+	}
+}
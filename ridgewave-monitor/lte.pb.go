@@ -0,0 +1,402 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.19.1
+// source: lte.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type LTESignal struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp     int64   `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // microseconds since epoch
+	PCI           int64   `protobuf:"varint,20,opt,name=PCI,proto3" json:"PCI,omitempty"`             // physical cell ID
+	CID           string  `protobuf:"bytes,30,opt,name=CID,proto3" json:"CID,omitempty"`              // cell ID, in hex
+	RSRP          int64   `protobuf:"varint,40,opt,name=RSRP,proto3" json:"RSRP,omitempty"`           // reference signal received power in dBm
+	RSRQ          float64 `protobuf:"fixed64,50,opt,name=RSRQ,proto3" json:"RSRQ,omitempty"`          // reference signal received quality in dB
+	RSSI          int64   `protobuf:"varint,60,opt,name=RSSI,proto3" json:"RSSI,omitempty"`           // received signal strength in dBm
+	SINR          float64 `protobuf:"fixed64,70,opt,name=SINR,proto3" json:"SINR,omitempty"`          // signal to interference plus noise ratio in dB
+	RxLev         int64   `protobuf:"varint,80,opt,name=RxLev,proto3" json:"RxLev,omitempty"`
+	State         string  `protobuf:"bytes,100,opt,name=State,proto3" json:"State,omitempty"` // connection state, such as "Connected"
+	WANIP         string  `protobuf:"bytes,110,opt,name=WANIP,proto3" json:"WANIP,omitempty"`
+	Band          int64   `protobuf:"varint,120,opt,name=Band,proto3" json:"Band,omitempty"`           // LTE band
+	EARFCN        int64   `protobuf:"varint,130,opt,name=EARFCN,proto3" json:"EARFCN,omitempty"`       // downlink channel number
+	BytesSent     int64   `protobuf:"varint,140,opt,name=BytesSent,proto3" json:"BytesSent,omitempty"` // cumulative since the modem connected
+	BytesReceived int64   `protobuf:"varint,150,opt,name=BytesReceived,proto3" json:"BytesReceived,omitempty"`
+	Uptime        int64   `protobuf:"varint,160,opt,name=Uptime,proto3" json:"Uptime,omitempty"` // seconds since the modem booted
+	Firmware      string  `protobuf:"bytes,170,opt,name=Firmware,proto3" json:"Firmware,omitempty"`
+}
+
+func (x *LTESignal) Reset() {
+	*x = LTESignal{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lte_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LTESignal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LTESignal) ProtoMessage() {}
+
+func (x *LTESignal) ProtoReflect() protoreflect.Message {
+	mi := &file_lte_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LTESignal.ProtoReflect.Descriptor instead.
+func (*LTESignal) Descriptor() ([]byte, []int) {
+	return file_lte_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *LTESignal) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LTESignal) GetPCI() int64 {
+	if x != nil {
+		return x.PCI
+	}
+	return 0
+}
+
+func (x *LTESignal) GetCID() string {
+	if x != nil {
+		return x.CID
+	}
+	return ""
+}
+
+func (x *LTESignal) GetRSRP() int64 {
+	if x != nil {
+		return x.RSRP
+	}
+	return 0
+}
+
+func (x *LTESignal) GetRSRQ() float64 {
+	if x != nil {
+		return x.RSRQ
+	}
+	return 0
+}
+
+func (x *LTESignal) GetRSSI() int64 {
+	if x != nil {
+		return x.RSSI
+	}
+	return 0
+}
+
+func (x *LTESignal) GetSINR() float64 {
+	if x != nil {
+		return x.SINR
+	}
+	return 0
+}
+
+func (x *LTESignal) GetRxLev() int64 {
+	if x != nil {
+		return x.RxLev
+	}
+	return 0
+}
+
+func (x *LTESignal) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *LTESignal) GetWANIP() string {
+	if x != nil {
+		return x.WANIP
+	}
+	return ""
+}
+
+func (x *LTESignal) GetBand() int64 {
+	if x != nil {
+		return x.Band
+	}
+	return 0
+}
+
+func (x *LTESignal) GetEARFCN() int64 {
+	if x != nil {
+		return x.EARFCN
+	}
+	return 0
+}
+
+func (x *LTESignal) GetBytesSent() int64 {
+	if x != nil {
+		return x.BytesSent
+	}
+	return 0
+}
+
+func (x *LTESignal) GetBytesReceived() int64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+func (x *LTESignal) GetUptime() int64 {
+	if x != nil {
+		return x.Uptime
+	}
+	return 0
+}
+
+func (x *LTESignal) GetFirmware() string {
+	if x != nil {
+		return x.Firmware
+	}
+	return ""
+}
+
+type LTEEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp int64   `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"` // microseconds since epoch
+	Kind      string  `protobuf:"bytes,20,opt,name=Kind,proto3" json:"Kind,omitempty"`            // handover, low-rsrp, rsrp-recovered, low-sinr, sinr-recovered, disconnect, reconnect, telnet-failure or telnet-recovered
+	Detail    string  `protobuf:"bytes,30,opt,name=Detail,proto3" json:"Detail,omitempty"`        // human-readable description of the event
+	PCI       int64   `protobuf:"varint,40,opt,name=PCI,proto3" json:"PCI,omitempty"`             // physical cell ID after the event, or 0 if disconnected
+	CID       string  `protobuf:"bytes,50,opt,name=CID,proto3" json:"CID,omitempty"`              // cell ID after the event, or empty if disconnected
+	RSRP      int64   `protobuf:"varint,60,opt,name=RSRP,proto3" json:"RSRP,omitempty"`           // reference signal received power in dBm after the event
+	SINR      float64 `protobuf:"fixed64,70,opt,name=SINR,proto3" json:"SINR,omitempty"`          // signal to interference plus noise ratio in dB after the event
+}
+
+func (x *LTEEvent) Reset() {
+	*x = LTEEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_lte_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LTEEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LTEEvent) ProtoMessage() {}
+
+func (x *LTEEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_lte_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LTEEvent.ProtoReflect.Descriptor instead.
+func (*LTEEvent) Descriptor() ([]byte, []int) {
+	return file_lte_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *LTEEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *LTEEvent) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *LTEEvent) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *LTEEvent) GetPCI() int64 {
+	if x != nil {
+		return x.PCI
+	}
+	return 0
+}
+
+func (x *LTEEvent) GetCID() string {
+	if x != nil {
+		return x.CID
+	}
+	return ""
+}
+
+func (x *LTEEvent) GetRSRP() int64 {
+	if x != nil {
+		return x.RSRP
+	}
+	return 0
+}
+
+func (x *LTEEvent) GetSINR() float64 {
+	if x != nil {
+		return x.SINR
+	}
+	return 0
+}
+
+var File_lte_proto protoreflect.FileDescriptor
+
+var file_lte_proto_rawDesc = []byte{
+	0x0a, 0x09, 0x6c, 0x74, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x74, 0x75, 0x74,
+	0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0x88, 0x03, 0x0a, 0x09, 0x4c, 0x54, 0x45, 0x53, 0x69, 0x67,
+	0x6e, 0x61, 0x6c, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x12, 0x10, 0x0a, 0x03, 0x50, 0x43, 0x49, 0x18, 0x14, 0x20, 0x01, 0x28, 0x03, 0x52, 0x03,
+	0x50, 0x43, 0x49, 0x12, 0x10, 0x0a, 0x03, 0x43, 0x49, 0x44, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x43, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x52, 0x53, 0x52, 0x50, 0x18, 0x28, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x04, 0x52, 0x53, 0x52, 0x50, 0x12, 0x12, 0x0a, 0x04, 0x52, 0x53, 0x52,
+	0x51, 0x18, 0x32, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x52, 0x53, 0x52, 0x51, 0x12, 0x12, 0x0a,
+	0x04, 0x52, 0x53, 0x53, 0x49, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x52, 0x53, 0x53,
+	0x49, 0x12, 0x12, 0x0a, 0x04, 0x53, 0x49, 0x4e, 0x52, 0x18, 0x46, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x04, 0x53, 0x49, 0x4e, 0x52, 0x12, 0x14, 0x0a, 0x05, 0x52, 0x78, 0x4c, 0x65, 0x76, 0x18, 0x50,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x52, 0x78, 0x4c, 0x65, 0x76, 0x12, 0x14, 0x0a, 0x05, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x18, 0x64, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x53, 0x74, 0x61, 0x74,
+	0x65, 0x12, 0x14, 0x0a, 0x05, 0x57, 0x41, 0x4e, 0x49, 0x50, 0x18, 0x6e, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x05, 0x57, 0x41, 0x4e, 0x49, 0x50, 0x12, 0x12, 0x0a, 0x04, 0x42, 0x61, 0x6e, 0x64, 0x18,
+	0x78, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x42, 0x61, 0x6e, 0x64, 0x12, 0x17, 0x0a, 0x06, 0x45,
+	0x41, 0x52, 0x46, 0x43, 0x4e, 0x18, 0x82, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x45, 0x41,
+	0x52, 0x46, 0x43, 0x4e, 0x12, 0x1d, 0x0a, 0x09, 0x42, 0x79, 0x74, 0x65, 0x73, 0x53, 0x65, 0x6e,
+	0x74, 0x18, 0x8c, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x42, 0x79, 0x74, 0x65, 0x73, 0x53,
+	0x65, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0d, 0x42, 0x79, 0x74, 0x65, 0x73, 0x52, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x18, 0x96, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x42, 0x79, 0x74,
+	0x65, 0x73, 0x52, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x12, 0x17, 0x0a, 0x06, 0x55, 0x70,
+	0x74, 0x69, 0x6d, 0x65, 0x18, 0xa0, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x55, 0x70, 0x74,
+	0x69, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x08, 0x46, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65, 0x18,
+	0xaa, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x46, 0x69, 0x72, 0x6d, 0x77, 0x61, 0x72, 0x65,
+	0x22, 0xa0, 0x01, 0x0a, 0x08, 0x4c, 0x54, 0x45, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x1c, 0x0a,
+	0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x4b,
+	0x69, 0x6e, 0x64, 0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12,
+	0x16, 0x0a, 0x06, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x18, 0x1e, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x44, 0x65, 0x74, 0x61, 0x69, 0x6c, 0x12, 0x10, 0x0a, 0x03, 0x50, 0x43, 0x49, 0x18, 0x28,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x03, 0x50, 0x43, 0x49, 0x12, 0x10, 0x0a, 0x03, 0x43, 0x49, 0x44,
+	0x18, 0x32, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x43, 0x49, 0x44, 0x12, 0x12, 0x0a, 0x04, 0x52,
+	0x53, 0x52, 0x50, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x04, 0x52, 0x53, 0x52, 0x50, 0x12,
+	0x12, 0x0a, 0x04, 0x53, 0x49, 0x4e, 0x52, 0x18, 0x46, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x53,
+	0x49, 0x4e, 0x52, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_lte_proto_rawDescOnce sync.Once
+	file_lte_proto_rawDescData = file_lte_proto_rawDesc
+)
+
+func file_lte_proto_rawDescGZIP() []byte {
+	file_lte_proto_rawDescOnce.Do(func() {
+		file_lte_proto_rawDescData = protoimpl.X.CompressGZIP(file_lte_proto_rawDescData)
+	})
+	return file_lte_proto_rawDescData
+}
+
+var file_lte_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_lte_proto_goTypes = []interface{}{
+	(*LTESignal)(nil), // 0: tutorial.LTESignal
+	(*LTEEvent)(nil),  // 1: tutorial.LTEEvent
+}
+var file_lte_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_lte_proto_init() }
+func file_lte_proto_init() {
+	if File_lte_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_lte_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LTESignal); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_lte_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*LTEEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_lte_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_lte_proto_goTypes,
+		DependencyIndexes: file_lte_proto_depIdxs,
+		MessageInfos:      file_lte_proto_msgTypes,
+	}.Build()
+	File_lte_proto = out.File
+	file_lte_proto_rawDesc = nil
+	file_lte_proto_goTypes = nil
+	file_lte_proto_depIdxs = nil
+}
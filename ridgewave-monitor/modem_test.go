@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/monasticacademy/maple-network-tools/telnetsession"
+	"github.com/reiver/go-telnet"
+)
+
+// telnet commands and options that the fake modem negotiates
+const (
+	telnetWILL = 251
+	telnetIAC  = 255
+
+	telnetOptionEcho            = 1
+	telnetOptionSuppressGoAhead = 3
+)
+
+// fakePrompt is the command prompt of the fake modem. The real prompt has not
+// been recorded, so this is a guess, see testdata/README.md.
+const fakePrompt = "RW-CLI> "
+
+// fakeModem is a telnet server that logs in like the Ridgewave and answers
+// its commands with the transcripts in testdata
+type fakeModem struct {
+	outputs map[string][]byte // output for each command, keyed by the exact command line
+	user    string
+	pass    string
+}
+
+// negotiatingListener offers to echo and to suppress go-ahead at the start of
+// each connection, as the modem does. This is written to the raw connection
+// because go-telnet escapes IAC bytes in everything written by a handler.
+type negotiatingListener struct {
+	net.Listener
+}
+
+func (l negotiatingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	_, err = conn.Write([]byte{
+		telnetIAC, telnetWILL, telnetOptionEcho,
+		telnetIAC, telnetWILL, telnetOptionSuppressGoAhead,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// startFakeModem serves the transcripts in testdata on a random localhost port
+// and returns the address. Entries in outputs replace the transcript of a
+// command, and an empty entry makes the command unknown.
+func startFakeModem(t *testing.T, user, pass string, outputs map[string]string) string {
+	f := fakeModem{
+		outputs: make(map[string][]byte),
+		user:    user,
+		pass:    pass,
+	}
+	for _, cmd := range modemCommands {
+		buf, err := os.ReadFile(filepath.Join("testdata", cmd.File))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.outputs[cmd.Command] = buf
+	}
+	for cmd, out := range outputs {
+		if out == "" {
+			delete(f.outputs, cmd)
+		} else {
+			f.outputs[cmd] = []byte(out)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go telnet.Serve(negotiatingListener{listener}, &f)
+	return listener.Addr().String()
+}
+
+// ServeTELNET asks for credentials and then answers commands until the client
+// sends "exit" or disconnects. Like the modem, it echoes what the client types.
+func (f *fakeModem) ServeTELNET(ctx telnet.Context, w telnet.Writer, r telnet.Reader) {
+	// readLine reads a line from the client and echoes it, except for
+	// passwords. This reads one byte at a time because reads from go-telnet
+	// block until the buffer is full.
+	readLine := func(echo bool) (string, bool) {
+		var buf [1]byte
+		var b strings.Builder
+		for buf[0] != '\n' {
+			_, err := r.Read(buf[:])
+			if err != nil {
+				return "", false
+			}
+			b.WriteByte(buf[0])
+		}
+		line := strings.TrimRight(b.String(), "\r\n")
+		if echo {
+			fmt.Fprint(w, line)
+		}
+		fmt.Fprint(w, "\r\n")
+		return line, true
+	}
+
+	for {
+		fmt.Fprint(w, "Login: ")
+		user, ok := readLine(true)
+		if !ok {
+			return
+		}
+		fmt.Fprint(w, "Password: ")
+		pass, ok := readLine(false)
+		if !ok {
+			return
+		}
+		if user == f.user && pass == f.pass {
+			break
+		}
+		fmt.Fprint(w, "Login incorrect\r\n\r\n")
+	}
+
+	fmt.Fprint(w, "\r\nRidgewave LTE CLI\r\n\r\n"+fakePrompt)
+	for {
+		cmd, ok := readLine(true)
+		if !ok || cmd == "exit" {
+			return
+		}
+
+		if out, ok := f.outputs[cmd]; ok {
+			w.Write([]byte(strings.ReplaceAll(string(out), "\n", "\r\n")))
+		} else if cmd != "" {
+			fmt.Fprintf(w, "Unknown command: %s\r\n", cmd)
+		}
+		fmt.Fprint(w, fakePrompt)
+	}
+}
+
+func TestReadModem(t *testing.T) {
+	addr := startFakeModem(t, "admin", "fake", nil)
+	info, status, err := readModem(modemConfig{Addr: addr, User: "admin", Pass: "fake", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantInfo := LTEInfo{PCI: 343, CID: "A2F03", RSRP: -91, RSRQ: -8.8, RSSI: -65, SINR: 30, RxLev: 0}
+	if *info != wantInfo {
+		t.Errorf("expected %+v, got %+v", wantInfo, *info)
+	}
+	if status.State != connectedState || status.Band != 13 || status.EARFCN != 5230 {
+		t.Errorf("expected connected on band 13 at EARFCN 5230, got %+v", *status)
+	}
+}
+
+func TestReadModemWithoutLTEInfo(t *testing.T) {
+	// while searching for a cell the modem prints no measurements, which is a
+	// disconnect rather than a failure to read the modem
+	addr := startFakeModem(t, "admin", "fake", map[string]string{
+		lteInfoCommand: "LTE Info:\n  Band(13) EARFCN(5230) Bandwidth(10MHz)\n",
+	})
+	info, status, err := readModem(modemConfig{Addr: addr, User: "admin", Pass: "fake", Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info != nil {
+		t.Errorf("expected no LTE info, got %+v", *info)
+	}
+	if status.State != noCellState {
+		t.Errorf("expected state %q, got %q", noCellState, status.State)
+	}
+}
+
+func TestReadModemBadLTEInfo(t *testing.T) {
+	addr := startFakeModem(t, "admin", "fake", map[string]string{
+		lteInfoCommand: "PCI(343) CID(A2F03) RSRP(n/a) RSRQ(-8.8) RSSI(-65) SINR(30.0) RxLev(0)\n",
+	})
+	_, _, err := readModem(modemConfig{Addr: addr, User: "admin", Pass: "fake", Timeout: time.Second})
+	if err == nil {
+		t.Error("expected an error for measurements that do not parse")
+	}
+}
+
+func TestReadModemLoginFailed(t *testing.T) {
+	addr := startFakeModem(t, "admin", "fake", nil)
+	_, _, err := readModem(modemConfig{Addr: addr, User: "admin", Pass: "wrong", Timeout: time.Second})
+	if !errors.Is(err, telnetsession.ErrLoginFailed) {
+		t.Errorf("expected ErrLoginFailed, got %v", err)
+	}
+}
@@ -1,22 +1,36 @@
+//go:generate protoc -I/usr/local/include -I. --go_out=. lte.proto
+
 package main
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 	"unicode"
 
+	storage "cloud.google.com/go/bigquery/storage/apiv1beta2"
 	"github.com/alexflint/go-arg"
 	"github.com/alexflint/go-restructure"
-	"github.com/kr/pretty"
-	"github.com/reiver/go-telnet"
+	"github.com/monasticacademy/maple-network-tools/sink"
+	"github.com/monasticacademy/maple-network-tools/telnetsession"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/option"
+	"google.golang.org/protobuf/proto"
 )
 
+const streamingTraceID = "ridgewave-monitor" // identifies this client in bigquery debug logs
+
 // LTELine is for matching lines that look like this:
-//    PCI(343) CID(A2F03) RSRP(-91) RSRQ(-8.8) RSSI(-65) SINR(30.0) RxLev(0)
+//
+//	PCI(343) CID(A2F03) RSRP(-91) RSRQ(-8.8) RSSI(-65) SINR(30.0) RxLev(0)
 type LTELine struct {
 	_     struct{} `regexp:"PCI\\("`
 	PCI   string   `regexp:".+"`
@@ -86,58 +100,388 @@ func infoFromLine(in LTELine) (*LTEInfo, error) {
 	return &out, nil
 }
 
+// errNoLTEInfo is returned by parseLTEInfo when the output has no PCI(...)
+// line, which the modem only prints while it has a serving cell
+var errNoLTEInfo = errors.New("modem output did not contain LTE info")
+
+// parseLTEInfo finds the signal measurements in the output of
+// "wan lte lteinfo"
+func parseLTEInfo(out string) (*LTEInfo, error) {
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.Map(func(r rune) rune {
+			if unicode.IsPrint(r) {
+				return r
+			}
+			return -1
+		}, line)
+
+		var match LTELine
+		if pattern.Find(&match, line) {
+			return infoFromLine(match)
+		}
+	}
+	return nil, errNoLTEInfo
+}
+
+// readModem logs in to the modem and runs each of modemCommands. The state is
+// connected if the modem prints signal measurements, and noCellState with nil
+// measurements if it does not. Errors parsing the rest of the output are only
+// logged, so the status holds whatever could be parsed.
+func readModem(modem modemConfig) (*LTEInfo, *ModemStatus, error) {
+	s, err := telnetsession.Dial(modem.Addr, modem.Timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.Close()
+
+	err = s.Login(modem.User, modem.Pass)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var info *LTEInfo
+	var status ModemStatus
+	for _, cmd := range modemCommands {
+		out, err := s.Run(cmd.Command)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if cmd.Command == lteInfoCommand {
+			info, err = parseLTEInfo(out)
+			switch {
+			case errors.Is(err, errNoLTEInfo):
+				status.State = noCellState
+			case err != nil:
+				return nil, nil, err
+			default:
+				status.State = connectedState
+			}
+		}
+
+		err = cmd.Parse(out, &status)
+		if err != nil {
+			log.Printf("error parsing output of %q: %v", cmd.Command, err)
+		}
+	}
+	return info, &status, nil
+}
+
+// captureTranscripts runs each of modemCommands and writes the output to the
+// transcript files in dir
+func captureTranscripts(modem modemConfig, dir string) error {
+	s, err := telnetsession.Dial(modem.Addr, modem.Timeout)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	err = s.Login(modem.User, modem.Pass)
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range modemCommands {
+		out, err := s.Run(cmd.Command)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(dir, cmd.File), []byte(out), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTranscripts parses the transcript files in dir, as if the modem had
+// printed them
+func parseTranscripts(dir string) (*ModemStatus, error) {
+	var status ModemStatus
+	for _, cmd := range modemCommands {
+		buf, err := os.ReadFile(filepath.Join(dir, cmd.File))
+		if err != nil {
+			return nil, err
+		}
+		err = cmd.Parse(string(buf), &status)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", cmd.File, err)
+		}
+	}
+	return &status, nil
+}
+
+// modemConfig is how to reach and log in to the modem
+type modemConfig struct {
+	Addr    string // host and port of the modem's telnet interface
+	User    string
+	Pass    string
+	Timeout time.Duration // how long to wait for each prompt
+}
+
+type app struct {
+	m         sync.Mutex
+	buf       [60]*LTESignal // ring buffer of most recent N entries
+	eventBuf  [20]*LTEEvent  // ring buffer of most recent N events
+	modem     modemConfig
+	sink      sink.Sink // where to send the rows
+	eventSink sink.Sink // where to send the events
+	detector  eventDetector
+	webhook   *webhook // where to post events, or nil if there is no webhook
+}
+
+// push adds a record to the "recent" buffer, possibly dropping old entries
+func (a *app) push(r *LTESignal) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	copy(a.buf[1:], a.buf[:len(a.buf)-1])
+	a.buf[0] = r
+}
+
+// latest gets the most recent signal records, newest is first
+func (a *app) latest() []*LTESignal {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	// make a copy to avoid data races
+	var out []*LTESignal
+	for _, r := range a.buf {
+		if r == nil {
+			break
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// pushEvent adds an event to the "recent events" buffer, possibly dropping old
+// entries
+func (a *app) pushEvent(e *LTEEvent) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	copy(a.eventBuf[1:], a.eventBuf[:len(a.eventBuf)-1])
+	a.eventBuf[0] = e
+}
+
+// latestEvents gets the most recent events, newest is first
+func (a *app) latestEvents() []*LTEEvent {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	var out []*LTEEvent
+	for _, e := range a.eventBuf {
+		if e == nil {
+			break
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// emit records events, sends them to the event sink and posts them to the
+// webhook. Errors are logged rather than returned so that the signal row is
+// still sent.
+func (a *app) emit(ctx context.Context, events []*LTEEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	var rows []proto.Message
+	for _, e := range events {
+		log.Printf("%s: %s", e.Kind, e.Detail)
+		a.pushEvent(e)
+		rows = append(rows, e)
+
+		if a.webhook != nil {
+			a.webhook.post("LTE backup " + e.Kind + ": " + e.Detail)
+		}
+	}
+
+	err := a.eventSink.Write(ctx, rows)
+	if err != nil {
+		log.Println("error sending events:", err)
+	}
+}
+
+// tick gets executed every interval. It reads the LTE signal from the modem.
+func (a *app) tick(ctx context.Context) error {
+	timestamp := time.Now()
+
+	info, status, err := readModem(a.modem)
+	if err != nil {
+		a.emit(ctx, a.detector.failed(timestamp, err))
+		return fmt.Errorf("error reading LTE info from modem: %w", err)
+	}
+
+	// the WAN IP, traffic, uptime and firmware columns stay empty until the
+	// commands that print them are known, see testdata/README.md
+	row := LTESignal{
+		Timestamp: timestamp.UnixMicro(),
+		State:     status.State,
+		Band:      int64(status.Band),
+		EARFCN:    int64(status.EARFCN),
+	}
+	if info != nil {
+		row.PCI = int64(info.PCI)
+		row.CID = info.CID
+		row.RSRP = int64(info.RSRP)
+		row.RSRQ = info.RSRQ
+		row.RSSI = int64(info.RSSI)
+		row.SINR = info.SINR
+		row.RxLev = int64(info.RxLev)
+	}
+	log.Printf("PCI %d CID %s RSRP %d RSRQ %.1f RSSI %d SINR %.1f band %d %s",
+		row.PCI, row.CID, row.RSRP, row.RSRQ, row.RSSI, row.SINR, row.Band, row.State)
+
+	// push the result onto the in-memory ring buffer
+	a.push(&row)
+	a.emit(ctx, a.detector.observe(&row))
+
+	return a.sink.Write(ctx, []proto.Message{&row})
+}
+
 func main() {
+	ctx := context.Background()
+
 	var args struct {
-		Modem string
+		Modem       string
+		User        string        `help:"Username for the modem" arg:"env:MODEM_USER"`
+		Pass        string        `help:"Password for the modem" arg:"env:MODEM_PASS"`
+		Timeout     time.Duration `help:"How long to wait for each prompt from the modem"`
+		Capture     string        `help:"Save the output of each command from the modem to this dir and exit"`
+		Parse       string        `help:"Parse the saved output of each command in this dir, print the status as JSON and exit"`
+		Port        string        `help:"Port for the HTTP user interface"`
+		Dataset     string        `help:"Bigquery dataset name"`
+		Table       string        `help:"Bigquery table name"`
+		EventTable  string        `help:"Bigquery table name for handovers, degradations and disconnects"`
+		MinRSRP     int64         `help:"RSRP in dBm below which the signal counts as degraded"`
+		MinSINR     float64       `help:"SINR in dB below which the signal counts as degraded"`
+		Webhook     string        `help:"Slack-compatible webhook to post events to" arg:"env:LTE_WEBHOOK"`
+		Credentials string        `help:"Service account key for bigquery; if empty then rows are printed instead"`
+		Interval    time.Duration
 	}
 	args.Modem = "ridgewave.maple.cml.me:23"
-	arg.MustParse(&args)
+	args.User = "admin"
+	args.Timeout = 10 * time.Second
+	args.Port = ":8000"
+	args.Dataset = "maple"
+	args.Table = "lte_signal"
+	args.EventTable = "lte_events"
+	args.MinRSRP = -110
+	args.MinSINR = 0
+	args.Interval = time.Minute
+	p := arg.MustParse(&args)
 
-	conn, err := telnet.DialTo(args.Modem)
-	if err != nil {
-		log.Fatal(err)
+	app := app{
+		modem: modemConfig{
+			Addr:    args.Modem,
+			User:    args.User,
+			Pass:    args.Pass,
+			Timeout: args.Timeout,
+		},
+		sink:      &sink.Print{W: os.Stdout},
+		eventSink: &sink.Print{W: os.Stdout},
+		detector: eventDetector{
+			thresholds: thresholds{
+				MinRSRP: args.MinRSRP,
+				MinSINR: args.MinSINR,
+			},
+		},
 	}
-	_ = conn
 
-	conn.Write([]byte("admin\r\n"))
-	time.Sleep(time.Second)
-	conn.Write([]byte("admin\r\n"))
-	time.Sleep(time.Second)
-	conn.Write([]byte("wan lte lteinfo\r\n"))
-	time.Sleep(time.Second)
+	// parse saved transcripts, for checking new captures
+	if args.Parse != "" {
+		status, err := parseTranscripts(args.Parse)
+		if err != nil {
+			log.Fatal(err)
+		}
+		buf, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(buf))
+		return
+	}
 
-	fmt.Println("reading...")
-	buf := make([]byte, 1)
-	var line bytes.Buffer
-	for {
-		_, err := conn.Read(buf)
-		if err == io.EOF {
-			break
+	if args.Pass == "" {
+		p.Fail("the modem password is required, use --pass or $MODEM_PASS")
+	}
+
+	if args.Capture != "" {
+		err := captureTranscripts(app.modem, args.Capture)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if args.Credentials != "" {
+		googleCredentials, err := os.ReadFile(args.Credentials)
+		if err != nil {
+			log.Fatal("error reading credentials: ", err)
+		}
+
+		// unpack google credentials
+		creds, err := google.CredentialsFromJSON(ctx, googleCredentials)
+		if err != nil {
+			log.Fatal("error parsing credentials: ", err)
 		}
+
+		log.Println("project:", creds.ProjectID)
+		log.Println("dataset:", args.Dataset)
+		log.Println("table:", args.Table)
+		log.Println("event table:", args.EventTable)
+
+		// create the bigquery client for stream insertion
+		bqClient, err := storage.NewBigQueryWriteClient(ctx,
+			option.WithCredentialsJSON(googleCredentials))
 		if err != nil {
 			log.Fatal(err)
 		}
+		defer bqClient.Close()
 
-		c := buf[0]
-		if c == '\r' || c == '\n' {
-			var match LTELine
-			if pattern.Find(&match, line.String()) {
-				info, err := infoFromLine(match)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
-				pretty.Println(info)
-			}
-			line.Reset()
-		} else if unicode.IsPrint(rune(c)) {
-			line.WriteByte(c)
+		app.sink, err = sink.NewBigQuery(ctx, bqClient, creds.ProjectID, args.Dataset, args.Table, &LTESignal{}, streamingTraceID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		app.eventSink, err = sink.NewBigQuery(ctx, bqClient, creds.ProjectID, args.Dataset, args.EventTable, &LTEEvent{}, streamingTraceID)
+		if err != nil {
+			log.Fatal(err)
 		}
+	}
+
+	log.Println("modem:", args.Modem)
+	log.Println("interval:", args.Interval)
+
+	if args.Webhook != "" {
+		app.webhook = newWebhook(args.Webhook)
+	}
 
-		//os.Stdout.Write(buf[:n])
-		//os.Stdout.Write(buf[:n])
-		//fmt.Printf("read %d bytes\n", n)
+	// start the web UI
+	go app.runWebUI(ctx, args.Port)
+
+	// run the tick function once
+	err := app.tick(ctx)
+	if err != nil {
+		log.Println(err)
 	}
 
-	fmt.Println("done")
+	// read the LTE signal every N minutes
+	ticker := time.NewTicker(args.Interval)
+
+outer:
+	for {
+		select {
+		case <-ctx.Done():
+			break outer
+
+		case <-ticker.C:
+			err = app.tick(ctx)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+	}
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ModemStatus is everything other than the signal measurements that we get
+// from the modem's command line
+type ModemStatus struct {
+	State  string // connectedState, or noCellState if there are no measurements
+	Band   int
+	EARFCN int
+}
+
+// modemCommand is a command for the modem's command line together with the
+// parser for its output
+type modemCommand struct {
+	Command string
+	File    string // name of the transcript of this command in testdata
+	Parse   func(out string, st *ModemStatus) error
+}
+
+// lteInfoCommand prints the signal measurements as well as the band
+const lteInfoCommand = "wan lte lteinfo"
+
+// noCellState is the connection state when "wan lte lteinfo" prints no
+// measurements, which the modem does while it has no serving cell
+const noCellState = "No cell"
+
+// modemCommands are run on every poll. "wan lte lteinfo" is the only command
+// known to exist on the Ridgewave, and only its PCI(...) line has been seen;
+// the band line in testdata is a guess. Other commands can be added here once
+// their output has been recorded from the modem with --capture.
+var modemCommands = []modemCommand{
+	{lteInfoCommand, "lteinfo.txt", parseBand},
+}
+
+var (
+	parenField = regexp.MustCompile(`([A-Za-z]\w*)\(([^)]*)\)`)
+	colonField = regexp.MustCompile(`^\s*([^:]+?)\s*:\s*(.+?)\s*$`)
+)
+
+// fields gets the "Name(value)" pairs and "Name: value" lines in the output
+// of a command, keyed by lowercase name
+func fields(out string) map[string]string {
+	m := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		for _, match := range parenField.FindAllStringSubmatch(line, -1) {
+			m[strings.ToLower(match[1])] = match[2]
+		}
+		if match := colonField.FindStringSubmatch(line); match != nil {
+			m[strings.ToLower(match[1])] = match[2]
+		}
+	}
+	return m
+}
+
+// lookup gets the first of the given names that is present
+func lookup(m map[string]string, names ...string) (string, bool) {
+	for _, name := range names {
+		if v, ok := m[name]; ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// parseBand parses the band and EARFCN from the output of "wan lte lteinfo"
+func parseBand(out string, st *ModemStatus) error {
+	m := fields(out)
+	band, ok := lookup(m, "band")
+	if !ok {
+		return errors.New("no band in output")
+	}
+
+	var err error
+	st.Band, err = strconv.Atoi(strings.TrimPrefix(strings.ToUpper(band), "B"))
+	if err != nil {
+		return fmt.Errorf("error parsing band: %w", err)
+	}
+
+	if earfcn, ok := lookup(m, "earfcn", "dl earfcn"); ok {
+		st.EARFCN, err = strconv.Atoi(earfcn)
+		if err != nil {
+			return fmt.Errorf("error parsing EARFCN: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+// The expected values below are written by hand from the transcript, whose
+// band line is unverified, see testdata/README.md.
+func TestParseTranscripts(t *testing.T) {
+	status, err := parseTranscripts("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := ModemStatus{Band: 13, EARFCN: 5230}
+	if *status != want {
+		t.Errorf("expected %+v, got %+v", want, *status)
+	}
+}
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name  string
+		parse func(string, *ModemStatus) error
+		out   string
+		want  ModemStatus
+		fails bool
+	}{
+		{"band", parseBand, "  Band(13) EARFCN(5230) Bandwidth(10MHz)", ModemStatus{Band: 13, EARFCN: 5230}, false},
+		{"band with prefix", parseBand, "Band: B4\nDL EARFCN: 2175", ModemStatus{Band: 4, EARFCN: 2175}, false},
+		{"band without earfcn", parseBand, "Band(2)", ModemStatus{Band: 2}, false},
+		{"no band", parseBand, "PCI(343) CID(A2F03)", ModemStatus{}, true},
+		{"bad band", parseBand, "Band(LTE)", ModemStatus{}, true},
+	}
+	for _, test := range tests {
+		var got ModemStatus
+		err := test.parse(test.out, &got)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", test.name, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %+v, got %+v", test.name, test.want, got)
+		}
+	}
+}
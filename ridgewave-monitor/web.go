@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+func (a *app) runWebUI(ctx context.Context, port string) {
+	// set up the routes
+	http.HandleFunc("/", a.handleRoot)
+
+	// start the http server
+	log.Println("listening on " + port)
+	err := http.ListenAndServe(port, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func (a *app) handleRoot(w http.ResponseWriter, r *http.Request) {
+	latest := a.latest()
+	if len(latest) == 0 {
+		fmt.Fprintln(w, "no LTE records in buffer")
+		return
+	}
+
+	cur := latest[0]
+	fmt.Fprintf(w, "%-6s %10d\n", "PCI", cur.PCI)
+	fmt.Fprintf(w, "%-6s %10s\n", "CID", cur.CID)
+	fmt.Fprintf(w, "%-6s %10d dBm\n", "RSRP", cur.RSRP)
+	fmt.Fprintf(w, "%-6s %10.1f dB\n", "RSRQ", cur.RSRQ)
+	fmt.Fprintf(w, "%-6s %10d dBm\n", "RSSI", cur.RSSI)
+	fmt.Fprintf(w, "%-6s %10.1f dB\n", "SINR", cur.SINR)
+
+	ts := time.UnixMicro(cur.Timestamp)
+	fmt.Fprintf(w, "\nas of %v ago\n", time.Since(ts).Round(time.Second))
+
+	// history, newest first
+	fmt.Fprintf(w, "\n%-10s %5s %8s %6s %6s %6s %6s\n", "TIME", "PCI", "CID", "RSRP", "RSRQ", "RSSI", "SINR")
+	for _, r := range latest {
+		fmt.Fprintf(w, "%-10s %5d %8s %6d %6.1f %6d %6.1f\n",
+			time.UnixMicro(r.Timestamp).Format("15:04:05"), r.PCI, r.CID, r.RSRP, r.RSRQ, r.RSSI, r.SINR)
+	}
+
+	// events, newest first
+	events := a.latestEvents()
+	if len(events) > 0 {
+		fmt.Fprintf(w, "\n%-16s %-15s %s\n", "TIME", "EVENT", "DETAIL")
+		for _, e := range events {
+			fmt.Fprintf(w, "%-16s %-15s %s\n",
+				time.UnixMicro(e.Timestamp).Format("Jan 02 15:04:05"), e.Kind, e.Detail)
+		}
+	}
+}
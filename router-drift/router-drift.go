@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexflint/go-arg"
+	"github.com/monasticacademy/maple-network-tools/routeros"
+	"github.com/monasticacademy/maple-network-tools/rsc"
+)
+
+// fetchExport runs /export on the router and returns the output
+func fetchExport(addr, user, pass, knownHosts string) ([]byte, error) {
+	client, err := routeros.DialKnownHosts(addr, user, pass, knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	return client.Output("/export")
+}
+
+// postWebhook posts a message to a slack-compatible incoming webhook
+func postWebhook(url, text string) error {
+	buf, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.Post(url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook returned status %s", resp.Status)
+	}
+	return nil
+}
+
+func main() {
+	var args struct {
+		Config     string `help:"Checked-in export to compare against"`
+		File       string `help:"Compare this export file instead of fetching the live config from the router"`
+		Router     string `help:"Hostname and port of router"`
+		User       string `help:"SSH username for router"`
+		Pass       string `help:"SSH password for router, or empty to use the SSH agent" arg:"env:PASS"`
+		KnownHosts string `help:"known_hosts file for verifying the router's host key"`
+		Webhook    string `help:"Slack-compatible webhook to post a summary to when the config has drifted" arg:"env:DRIFT_WEBHOOK"`
+	}
+	args.Config = "../router/router.rsc"
+	args.Router = "microtik.maple.cml.me:22"
+	args.User = "traffic-monitor"
+	if home, err := os.UserHomeDir(); err == nil {
+		args.KnownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	arg.MustParse(&args)
+
+	// load the checked-in config
+	f, err := os.Open(args.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	want, err := rsc.Parse(f)
+	if err != nil {
+		log.Fatalf("error parsing %s: %v", args.Config, err)
+	}
+
+	// load the live config, either from a file or from the router
+	var live []byte
+	var source string
+	if args.File != "" {
+		source = args.File
+		live, err = os.ReadFile(args.File)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		source = args.Router
+		live, err = fetchExport(args.Router, args.User, args.Pass, args.KnownHosts)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	got, err := rsc.Parse(bytes.NewReader(live))
+	if err != nil {
+		log.Fatalf("error parsing export from %s: %v", source, err)
+	}
+
+	diffs := rsc.Diff(want, got)
+	if len(diffs) == 0 {
+		log.Printf("%s matches %s", source, args.Config)
+		return
+	}
+
+	added, removed, changed := rsc.Counts(diffs)
+	headline := fmt.Sprintf("router config at %s has drifted from %s: %d added, %d removed, %d changed in %d sections",
+		source, args.Config, added, removed, changed, len(diffs))
+	summary := rsc.Summary(diffs)
+
+	fmt.Println(headline)
+	fmt.Print(summary)
+
+	if args.Webhook != "" {
+		err = postWebhook(args.Webhook, headline+"\n```\n"+strings.TrimSpace(summary)+"\n```")
+		if err != nil {
+			log.Println("error posting to webhook:", err)
+		}
+	}
+
+	os.Exit(1)
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alexflint/go-arg"
+	"github.com/monasticacademy/maple-network-tools/routeros"
+	"github.com/monasticacademy/maple-network-tools/rsc"
+)
+
+type listArgs struct{}
+
+type dnsAddArgs struct {
+	Name    string `arg:"positional,required" help:"hostname, e.g. synology.maple.cml.me"`
+	Address string `arg:"positional,required" help:"IP address"`
+	Comment string `help:"comment for the entry"`
+	Alias   bool   `help:"allow the address to be shared with an existing name"`
+}
+
+type dnsRemoveArgs struct {
+	Name string `arg:"positional,required" help:"hostname to remove"`
+}
+
+type dnsArgs struct {
+	List   *listArgs      `arg:"subcommand:list" help:"list static DNS entries"`
+	Add    *dnsAddArgs    `arg:"subcommand:add" help:"add a static DNS entry"`
+	Remove *dnsRemoveArgs `arg:"subcommand:remove" help:"remove a static DNS entry"`
+}
+
+type leaseAddArgs struct {
+	Address string `arg:"positional,required" help:"IP address to reserve"`
+	MAC     string `arg:"positional,required" help:"MAC address of the device"`
+	Comment string `help:"comment for the lease, e.g. the name of the device"`
+	Server  string `help:"DHCP server for the lease" default:"defconf"`
+}
+
+type leaseRemoveArgs struct {
+	Device string `arg:"positional,required" help:"IP or MAC address of the lease to remove"`
+}
+
+type leaseArgs struct {
+	List   *listArgs        `arg:"subcommand:list" help:"list reserved DHCP leases"`
+	Add    *leaseAddArgs    `arg:"subcommand:add" help:"reserve an address for a device"`
+	Remove *leaseRemoveArgs `arg:"subcommand:remove" help:"remove a reserved lease"`
+}
+
+type args struct {
+	Config     string `help:"RouterOS export to read and update"`
+	Live       bool   `help:"also apply changes to the router over SSH"`
+	Router     string `help:"Hostname and port of router"`
+	User       string `help:"SSH username for router"`
+	Pass       string `help:"SSH password for router, or empty to use the SSH agent" arg:"env:PASS"`
+	KnownHosts string `help:"known_hosts file for verifying the router's host key"`
+
+	DNS   *dnsArgs   `arg:"subcommand:dns" help:"manage static DNS entries"`
+	Lease *leaseArgs `arg:"subcommand:lease" help:"manage reserved DHCP leases"`
+}
+
+// load reads the export
+func load(path string) (*rsc.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := rsc.ParseDocument(f)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+// save writes the export to a temporary file then renames it so that the export
+// is never left half-written
+func save(path string, doc *rsc.Document) error {
+	var b bytes.Buffer
+	_, err := doc.WriteTo(&b)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	err = os.WriteFile(tmp, b.Bytes(), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// validate reads back a saved export and checks that its DNS entries and leases
+// can be parsed
+func validate(path string) error {
+	doc, err := load(path)
+	if err != nil {
+		return err
+	}
+	_, err = doc.StaticDNS()
+	if err != nil {
+		return fmt.Errorf("error validating %s: %w", path, err)
+	}
+	_, err = doc.Leases()
+	if err != nil {
+		return fmt.Errorf("error validating %s: %w", path, err)
+	}
+	return nil
+}
+
+// namesByAddress gets the static DNS names for each address
+func namesByAddress(doc *rsc.Document) (map[netip.Addr][]string, error) {
+	entries, err := doc.StaticDNS()
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[netip.Addr][]string)
+	for _, r := range entries {
+		names[r.Address] = append(names[r.Address], r.Name)
+	}
+	return names, nil
+}
+
+func listDNS(doc *rsc.Document) error {
+	entries, err := doc.StaticDNS()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tADDRESS\tCOMMENT")
+	for _, r := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.Name, r.Address, r.Comment)
+	}
+	return w.Flush()
+}
+
+func listLeases(doc *rsc.Document) error {
+	leases, err := doc.Leases()
+	if err != nil {
+		return err
+	}
+	names, err := namesByAddress(doc)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ADDRESS\tMAC\tSERVER\tDNS NAMES\tCOMMENT")
+	for _, r := range leases {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Address, r.MAC, r.Server, strings.Join(names[r.Address], ","), r.Comment)
+	}
+	return w.Flush()
+}
+
+// addDNS adds a static DNS entry to the export and returns the command that
+// adds it to the router. If live is not nil then the router is checked first.
+func addDNS(doc *rsc.Document, live *routeros.Client, a *dnsAddArgs) (string, error) {
+	addr, err := netip.ParseAddr(a.Address)
+	if err != nil {
+		return "", err
+	}
+	r := rsc.StaticDNS{Name: a.Name, Address: addr, Comment: a.Comment}
+
+	// refuse to add a name twice, or to reuse an address unless asked to
+	entries, err := doc.StaticDNS()
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if strings.EqualFold(e.Name, r.Name) {
+			return "", fmt.Errorf("%s already points to %s", e.Name, e.Address)
+		}
+		if e.Address == r.Address && !a.Alias {
+			return "", fmt.Errorf("%s is already used by %s (use --alias to add another name for it)", e.Address, e.Name)
+		}
+	}
+
+	if live != nil {
+		n, err := live.Count(rsc.StaticDNSPath, "name="+rsc.Quote(r.Name))
+		if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			return "", fmt.Errorf("%s already exists on the router", r.Name)
+		}
+	}
+
+	doc.Append(rsc.StaticDNSPath, r.Entry())
+	log.Printf("added %s -> %s", r.Name, r.Address)
+	return rsc.StaticDNSPath + " " + r.Entry().String(), nil
+}
+
+// removeDNS removes a static DNS entry from the export and returns the command
+// that removes it from the router. An entry that is not in the export is
+// refused even with --live, so that the export and the router stay in step.
+func removeDNS(doc *rsc.Document, a *dnsRemoveArgs) (string, error) {
+	n := doc.Remove(rsc.StaticDNSPath, func(e rsc.Entry) bool {
+		name, _ := e.Get("name")
+		return strings.EqualFold(name, a.Name)
+	})
+	if n == 0 {
+		return "", fmt.Errorf("there is no static DNS entry for %s", a.Name)
+	}
+
+	log.Printf("removed %s", a.Name)
+	return fmt.Sprintf(`%s remove [find name=%s]`, rsc.StaticDNSPath, rsc.Quote(a.Name)), nil
+}
+
+// addLease reserves an address in the export and returns the command that
+// reserves it on the router. If live is not nil then the router is checked first.
+func addLease(doc *rsc.Document, live *routeros.Client, a *leaseAddArgs) (string, error) {
+	addr, err := netip.ParseAddr(a.Address)
+	if err != nil {
+		return "", err
+	}
+	mac, err := rsc.ParseMAC(a.MAC)
+	if err != nil {
+		return "", err
+	}
+	r := rsc.Lease{Address: addr, MAC: mac, Server: a.Server, Comment: a.Comment}
+
+	// refuse to reserve an address or a device twice
+	leases, err := doc.Leases()
+	if err != nil {
+		return "", err
+	}
+	for _, l := range leases {
+		if l.Address == r.Address {
+			return "", fmt.Errorf("%s is already reserved for %s", l.Address, l.MAC)
+		}
+		if l.MAC == r.MAC {
+			return "", fmt.Errorf("%s already has a reserved address %s", l.MAC, l.Address)
+		}
+	}
+
+	if live != nil {
+		n, err := live.Count(rsc.LeasePath, fmt.Sprintf(`address=%s or mac-address=%s`,
+			rsc.Quote(r.Address.String()), rsc.Quote(r.MAC)))
+		if err != nil {
+			return "", err
+		}
+		if n > 0 {
+			return "", fmt.Errorf("the router already has a lease for %s or %s", r.Address, r.MAC)
+		}
+	}
+
+	doc.Append(rsc.LeasePath, r.Entry())
+	log.Printf("reserved %s for %s", r.Address, r.MAC)
+	return rsc.LeasePath + " " + r.Entry().String(), nil
+}
+
+// removeLease removes a reserved lease from the export and returns the command
+// that removes it from the router. A lease that is not in the export is
+// refused even with --live.
+func removeLease(doc *rsc.Document, a *leaseRemoveArgs) (string, error) {
+	// the device can be given either as an IP address or as a MAC address
+	var key, value string
+	if addr, err := netip.ParseAddr(a.Device); err == nil {
+		key, value = "address", addr.String()
+	} else if mac, err := rsc.ParseMAC(a.Device); err == nil {
+		key, value = "mac-address", mac
+	} else {
+		return "", fmt.Errorf("%s is neither an IP address nor a MAC address", a.Device)
+	}
+
+	n := doc.Remove(rsc.LeasePath, func(e rsc.Entry) bool {
+		v, _ := e.Get(key)
+		return strings.EqualFold(v, value)
+	})
+	if n == 0 {
+		return "", fmt.Errorf("there is no reserved lease for %s", a.Device)
+	}
+
+	log.Printf("removed lease for %s", a.Device)
+	return fmt.Sprintf(`%s remove [find %s=%s]`, rsc.LeasePath, key, rsc.Quote(value)), nil
+}
+
+func main() {
+	var args args
+	args.Config = "../router/router.rsc"
+	args.Router = "microtik.maple.cml.me:22"
+	args.User = "admin"
+	if home, err := os.UserHomeDir(); err == nil {
+		args.KnownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	p := arg.MustParse(&args)
+
+	doc, err := load(args.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// list commands only read the export
+	switch {
+	case args.DNS == nil && args.Lease == nil:
+		p.Fail("you must specify dns or lease")
+	case args.DNS != nil && args.DNS.List != nil:
+		err = listDNS(doc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	case args.Lease != nil && args.Lease.List != nil:
+		err = listLeases(doc)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var live *routeros.Client
+	if args.Live {
+		live, err = routeros.DialKnownHosts(args.Router, args.User, args.Pass, args.KnownHosts)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer live.Close()
+	}
+
+	var cmd string
+	switch {
+	case args.DNS != nil && args.DNS.Add != nil:
+		cmd, err = addDNS(doc, live, args.DNS.Add)
+	case args.DNS != nil && args.DNS.Remove != nil:
+		cmd, err = removeDNS(doc, args.DNS.Remove)
+	case args.Lease != nil && args.Lease.Add != nil:
+		cmd, err = addLease(doc, live, args.Lease.Add)
+	case args.Lease != nil && args.Lease.Remove != nil:
+		cmd, err = removeLease(doc, args.Lease.Remove)
+	default:
+		p.Fail("you must specify list, add, or remove")
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// write the export and check that it can be read back before changing the
+	// router, so that the router never holds a change that the export lacks
+	err = save(args.Config, doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = validate(args.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if live != nil {
+		err = live.Run(cmd)
+		if err != nil {
+			log.Fatalf("%s was updated but the router was not: %v", args.Config, err)
+		}
+		log.Println("applied to the router:", cmd)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/monasticacademy/maple-network-tools/rsc"
+)
+
+const testExport = `/ip dhcp-server lease
+add address=192.168.88.250 mac-address=90:09:D0:00:60:B7 server=defconf
+/ip dns static
+add address=192.168.88.250 name=synology.maple.cml.me
+`
+
+func parseTestExport(t *testing.T) *rsc.Document {
+	t.Helper()
+	doc, err := rsc.ParseDocument(strings.NewReader(testExport))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+// export writes the document back out as text
+func export(t *testing.T, doc *rsc.Document) string {
+	t.Helper()
+	var b strings.Builder
+	if _, err := doc.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestRemoveCommandsAreQuoted(t *testing.T) {
+	doc := parseTestExport(t)
+
+	cmd, err := removeDNS(doc, &dnsRemoveArgs{Name: "synology.maple.cml.me"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `/ip dns static remove [find name="synology.maple.cml.me"]`; cmd != want {
+		t.Errorf("got %q, want %q", cmd, want)
+	}
+
+	cmd, err = removeLease(doc, &leaseRemoveArgs{Device: "90:09:d0:00:60:b7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `/ip dhcp-server lease remove [find mac-address="90:09:D0:00:60:B7"]`; cmd != want {
+		t.Errorf("got %q, want %q", cmd, want)
+	}
+}
+
+func TestAddDNSEscapesName(t *testing.T) {
+	doc := parseTestExport(t)
+
+	// a name that would end the string and run a command if it were not escaped
+	name := `x"];/system reboot;[find name="$y`
+	cmd, err := addDNS(doc, nil, &dnsAddArgs{Name: name, Address: "192.168.88.40"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `/ip dns static add address=192.168.88.40 name="x\"];/system reboot;[find name=\"\$y"`
+	if cmd != want {
+		t.Errorf("got %q, want %q", cmd, want)
+	}
+
+	cmd, err = removeDNS(doc, &dnsRemoveArgs{Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `/ip dns static remove [find name="x\"];/system reboot;[find name=\"\$y"]`; cmd != want {
+		t.Errorf("got %q, want %q", cmd, want)
+	}
+}
+
+func TestSaveThenValidate(t *testing.T) {
+	doc := parseTestExport(t)
+	_, err := addLease(doc, nil, &leaseAddArgs{Address: "192.168.88.40", MAC: "AA:BB:CC:DD:EE:01", Server: "defconf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "router.rsc")
+	if err := save(path, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := validate(path); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leases, err := saved.Leases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leases) != 2 || leases[1].MAC != "AA:BB:CC:DD:EE:01" {
+		t.Errorf("expected the new lease to be saved, got %v", leases)
+	}
+}
+
+func TestAddRefusesConflicts(t *testing.T) {
+	cases := []struct {
+		name string
+		add  func(doc *rsc.Document) (string, error)
+	}{
+		{"duplicate DNS name", func(doc *rsc.Document) (string, error) {
+			return addDNS(doc, nil, &dnsAddArgs{Name: "Synology.maple.cml.me", Address: "192.168.88.41"})
+		}},
+		{"duplicate DNS name with alias", func(doc *rsc.Document) (string, error) {
+			return addDNS(doc, nil, &dnsAddArgs{Name: "synology.maple.cml.me", Address: "192.168.88.250", Alias: true})
+		}},
+		{"DNS address without alias", func(doc *rsc.Document) (string, error) {
+			return addDNS(doc, nil, &dnsAddArgs{Name: "nas.maple.cml.me", Address: "192.168.88.250"})
+		}},
+		{"duplicate lease address", func(doc *rsc.Document) (string, error) {
+			return addLease(doc, nil, &leaseAddArgs{Address: "192.168.88.250", MAC: "AA:BB:CC:DD:EE:01", Server: "defconf"})
+		}},
+		{"duplicate lease MAC", func(doc *rsc.Document) (string, error) {
+			return addLease(doc, nil, &leaseAddArgs{Address: "192.168.88.40", MAC: "90-09-d0-00-60-b7", Server: "defconf"})
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			doc := parseTestExport(t)
+			before := export(t, doc)
+			_, err := c.add(doc)
+			if err == nil {
+				t.Fatal("expected the conflicting entry to be refused")
+			}
+			if export(t, doc) != before {
+				t.Error("expected the export to be unchanged")
+			}
+		})
+	}
+}
+
+func TestAddDNSAlias(t *testing.T) {
+	doc := parseTestExport(t)
+	_, err := addDNS(doc, nil, &dnsAddArgs{Name: "nas.maple.cml.me", Address: "192.168.88.250", Alias: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := namesByAddress(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, n := range names {
+		if len(n) != 2 {
+			t.Errorf("expected two names for %s, got %v", addr, n)
+		}
+	}
+}
+
+func TestRemoveRefusesMissingEntries(t *testing.T) {
+	doc := parseTestExport(t)
+	before := export(t, doc)
+
+	if _, err := removeDNS(doc, &dnsRemoveArgs{Name: "printer.maple.cml.me"}); err == nil {
+		t.Error("expected an error removing a DNS name that is not in the export")
+	}
+	if _, err := removeLease(doc, &leaseRemoveArgs{Device: "192.168.88.41"}); err == nil {
+		t.Error("expected an error removing a lease for an address that is not in the export")
+	}
+	if _, err := removeLease(doc, &leaseRemoveArgs{Device: "AA:BB:CC:DD:EE:01"}); err == nil {
+		t.Error("expected an error removing a lease for a MAC that is not in the export")
+	}
+	if export(t, doc) != before {
+		t.Error("expected the export to be unchanged")
+	}
+}
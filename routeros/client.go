@@ -0,0 +1,116 @@
+// Package routeros runs commands on a MikroTik router over SSH
+package routeros
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Client runs RouterOS commands over an SSH connection, one session per command
+type Client struct {
+	client *ssh.Client
+}
+
+// Dial connects to the router at addr, which includes the port
+func Dial(addr string, config *ssh.ClientConfig) (*Client, error) {
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("error sshing to router: %w", err)
+	}
+	return &Client{client: client}, nil
+}
+
+// DialKnownHosts connects to the router, checking its host key against a
+// known_hosts file and logging in with the password if one is given, otherwise
+// with the SSH agent
+func DialKnownHosts(addr, user, pass, knownHostsPath string) (*Client, error) {
+	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("error loading known hosts: %w", err)
+	}
+
+	auth, err := Auth(pass)
+	if err != nil {
+		return nil, err
+	}
+
+	return Dial(addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	})
+}
+
+// Auth uses the password if one was given, otherwise the SSH agent
+func Auth(pass string) ([]ssh.AuthMethod, error) {
+	if pass != "" {
+		return []ssh.AuthMethod{ssh.Password(pass)}, nil
+	}
+
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("no password given and SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to SSH agent: %w", err)
+	}
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(agent.NewClient(conn).Signers)}, nil
+}
+
+// Output runs a command and returns what it printed
+func (c *Client) Output(cmd string) ([]byte, error) {
+	session, err := c.client.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("error opening SSH session: %w", err)
+	}
+	defer session.Close()
+
+	out, err := session.CombinedOutput(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("error running %q: %w: %s", cmd, err, out)
+	}
+	return out, nil
+}
+
+// Run runs a command that is expected to print nothing. RouterOS does not
+// always set an exit status when a command fails, so any output is treated as
+// an error.
+func (c *Client) Run(cmd string) error {
+	out, err := c.Output(cmd)
+	if err != nil {
+		return err
+	}
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("error running %q: %s", cmd, msg)
+	}
+	return nil
+}
+
+// Count gets the number of entries in a section that match a "where" clause
+func (c *Client) Count(path, where string) (int, error) {
+	cmd := fmt.Sprintf(":put [%s print count-only where %s]", path, where)
+	out, err := c.Output(cmd)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected output from %q: %q", cmd, out)
+	}
+	return n, nil
+}
+
+// Close closes the SSH connection
+func (c *Client) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,196 @@
+package rsc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// orderedSections are the sections in which the order of commands matters, so a
+// change of order is reported rather than ignored
+var orderedSections = map[string]bool{
+	"/ip firewall filter":   true,
+	"/ip firewall nat":      true,
+	"/ip firewall mangle":   true,
+	"/ip firewall raw":      true,
+	"/ipv6 firewall filter": true,
+	"/ipv6 firewall mangle": true,
+	"/ipv6 firewall raw":    true,
+	"/routing filter":       true,
+}
+
+// identityKeys are the properties that identify an entry, in order of preference,
+// so that an entry that was removed and another that was added with the same
+// identity are reported as a single change
+var identityKeys = []string{"name", "mac-address", "address", "comment", "interface"}
+
+// Change is a command that exists on both sides with different arguments
+type Change struct {
+	Old string
+	New string
+}
+
+// SectionDiff is the difference between two versions of one section
+type SectionDiff struct {
+	Path      string
+	Added     []string // commands that are only in the new config
+	Removed   []string // commands that are only in the old config
+	Changed   []Change // commands that were modified
+	Reordered bool     // same commands in a different order, for sections where order matters
+}
+
+// Empty reports whether there are no differences
+func (d *SectionDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 && !d.Reordered
+}
+
+// Diff compares two configs section by section. Sections are matched by path so
+// the order of sections does not matter, and within most sections the order of
+// commands does not matter either.
+func Diff(old, new *Config) []*SectionDiff {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, c := range []*Config{old, new} {
+		for _, s := range c.Sections {
+			if !seen[s.Path] {
+				seen[s.Path] = true
+				paths = append(paths, s.Path)
+			}
+		}
+	}
+
+	var out []*SectionDiff
+	for _, path := range paths {
+		var oldLines, newLines []string
+		if s := old.Section(path); s != nil {
+			oldLines = s.Lines
+		}
+		if s := new.Section(path); s != nil {
+			newLines = s.Lines
+		}
+
+		d := diffLines(path, oldLines, newLines)
+		if !d.Empty() {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func diffLines(path string, old, new []string) *SectionDiff {
+	d := SectionDiff{Path: path}
+
+	// count lines on each side so that duplicates are handled correctly
+	counts := make(map[string]int)
+	for _, line := range old {
+		counts[line]++
+	}
+	for _, line := range new {
+		counts[line]--
+	}
+	for _, line := range old {
+		if counts[line] > 0 {
+			d.Removed = append(d.Removed, line)
+			counts[line]--
+		}
+	}
+	for _, line := range new {
+		if counts[line] < 0 {
+			d.Added = append(d.Added, line)
+			counts[line]++
+		}
+	}
+
+	// pair up removed and added lines that refer to the same entry
+	removedByID := make(map[string]int)
+	for i, line := range d.Removed {
+		if id := identity(line); id != "" {
+			removedByID[id] = i
+		}
+	}
+	var added []string
+	pairedRemoved := make(map[int]bool)
+	for _, line := range d.Added {
+		id := identity(line)
+		if i, ok := removedByID[id]; ok && id != "" && !pairedRemoved[i] {
+			d.Changed = append(d.Changed, Change{Old: d.Removed[i], New: line})
+			pairedRemoved[i] = true
+			continue
+		}
+		added = append(added, line)
+	}
+	var removed []string
+	for i, line := range d.Removed {
+		if !pairedRemoved[i] {
+			removed = append(removed, line)
+		}
+	}
+	d.Added, d.Removed = added, removed
+
+	// report reordering only where it matters
+	if orderedSections[path] && d.Empty() && !equal(old, new) {
+		d.Reordered = true
+	}
+	return &d
+}
+
+// identity gets a string that identifies the entry that a command refers to
+func identity(line string) string {
+	e := ParseEntry(line)
+	if e.Command == "set" && len(e.Positional) > 0 {
+		return "set " + strings.Join(e.Positional, " ")
+	}
+	for _, key := range identityKeys {
+		if v, ok := e.Get(key); ok {
+			return e.Command + " " + key + "=" + v
+		}
+	}
+	if e.Command == "set" {
+		return "set"
+	}
+	return ""
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary formats the differences for a human, with "+" for added, "-" for
+// removed, and "~" for changed commands
+func Summary(diffs []*SectionDiff) string {
+	var b strings.Builder
+	for _, d := range diffs {
+		fmt.Fprintln(&b, d.Path)
+		for _, line := range d.Removed {
+			fmt.Fprintf(&b, "  - %s\n", line)
+		}
+		for _, line := range d.Added {
+			fmt.Fprintf(&b, "  + %s\n", line)
+		}
+		for _, c := range d.Changed {
+			fmt.Fprintf(&b, "  ~ %s\n", c.New)
+			fmt.Fprintf(&b, "    (was %s)\n", c.Old)
+		}
+		if d.Reordered {
+			fmt.Fprintln(&b, "  commands were reordered")
+		}
+	}
+	return b.String()
+}
+
+// Counts gets the total number of added, removed, and changed commands
+func Counts(diffs []*SectionDiff) (added, removed, changed int) {
+	for _, d := range diffs {
+		added += len(d.Added)
+		removed += len(d.Removed)
+		changed += len(d.Changed)
+	}
+	return
+}
@@ -0,0 +1,67 @@
+package rsc
+
+import (
+	"os"
+	"testing"
+)
+
+func parseFile(t *testing.T, path string) *Config {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	c, err := Parse(f)
+	if err != nil {
+		t.Fatalf("error parsing %s: %v", path, err)
+	}
+	return c
+}
+
+func TestDiffSame(t *testing.T) {
+	want := parseFile(t, "../router/router.rsc")
+	got := parseFile(t, "../router-drift/testdata/same.rsc")
+
+	diffs := Diff(want, got)
+	if len(diffs) != 0 {
+		t.Errorf("expected no diff, got:\n%s", Summary(diffs))
+	}
+}
+
+func TestDiffDrifted(t *testing.T) {
+	want := parseFile(t, "../router/router.rsc")
+	got := parseFile(t, "../router-drift/testdata/drifted.rsc")
+
+	diffs := Diff(want, got)
+	if len(diffs) != 1 {
+		t.Fatalf("expected drift in one section, got:\n%s", Summary(diffs))
+	}
+
+	d := diffs[0]
+	if d.Path != "/ip dns static" {
+		t.Errorf("expected drift in /ip dns static, got %s", d.Path)
+	}
+	if len(d.Added) != 1 || len(d.Removed) != 1 || len(d.Changed) != 1 || d.Reordered {
+		t.Errorf("expected 1 added, 1 removed and 1 changed, got:\n%s", Summary(diffs))
+	}
+}
+
+func TestDiffReordered(t *testing.T) {
+	want := parseFile(t, "../router/router.rsc")
+	got := parseFile(t, "../router-drift/testdata/reordered.rsc")
+
+	diffs := Diff(want, got)
+	if len(diffs) != 1 {
+		t.Fatalf("expected drift in one section, got:\n%s", Summary(diffs))
+	}
+
+	d := diffs[0]
+	if d.Path != "/ip firewall filter" || !d.Reordered {
+		t.Errorf("expected /ip firewall filter to be reordered, got:\n%s", Summary(diffs))
+	}
+	if len(d.Added) != 0 || len(d.Removed) != 0 || len(d.Changed) != 0 {
+		t.Errorf("expected only a reorder, got:\n%s", Summary(diffs))
+	}
+}
@@ -0,0 +1,169 @@
+package rsc
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Document is an export that keeps the original text of every line, so that it
+// can be edited and written back without reformatting the parts that did not
+// change
+type Document struct {
+	Nodes []*Node
+}
+
+// Node is a section header, a command, or a comment or blank line
+type Node struct {
+	Path    string   // the section that this node belongs to, or is the header for
+	Header  bool     // whether this node starts a section
+	Command string   // the command with continuations joined, or empty for comments
+	Text    []string // the lines as they appear in the file
+}
+
+// Entry parses the command in this node
+func (n *Node) Entry() Entry {
+	return ParseEntry(n.Command)
+}
+
+// ParseDocument reads an export, keeping its formatting
+func ParseDocument(r io.Reader) (*Document, error) {
+	var d Document
+	var path string
+	var text []string           // lines of a command split over several lines
+	var pending strings.Builder // the command with continuations joined
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		raw := strings.TrimRight(s.Text(), "\r")
+		text = append(text, raw)
+
+		// join continuation lines
+		line := raw
+		if pending.Len() > 0 {
+			line = strings.TrimLeft(line, " ")
+		}
+		if strings.HasSuffix(line, "\\") {
+			pending.WriteString(strings.TrimSuffix(line, "\\"))
+			continue
+		}
+		if pending.Len() > 0 {
+			pending.WriteString(line)
+			line = pending.String()
+			pending.Reset()
+		}
+
+		n := Node{Path: path, Text: text}
+		text = nil
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			// keep comments and blank lines as they are
+		case strings.HasPrefix(line, "/") && !strings.Contains(line, "="):
+			// a line starting with a path and no command starts a new section
+			path = line
+			n.Path = line
+			n.Header = true
+		default:
+			if n.Path == "" {
+				n.Path = "/"
+			}
+			n.Command = line
+		}
+		d.Nodes = append(d.Nodes, &n)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(text) > 0 {
+		// keep a dangling continuation at the end of the file but do not parse it
+		d.Nodes = append(d.Nodes, &Node{Path: path, Text: text})
+	}
+	return &d, nil
+}
+
+// Config gets the normalized commands in each section, for comparing exports
+func (d *Document) Config() *Config {
+	var c Config
+	for _, n := range d.Nodes {
+		if n.Header && c.Section(n.Path) == nil {
+			c.Sections = append(c.Sections, &Section{Path: n.Path})
+		}
+		if n.Command == "" {
+			continue
+		}
+		s := c.Section(n.Path)
+		if s == nil {
+			s = &Section{Path: n.Path}
+			c.Sections = append(c.Sections, s)
+		}
+		s.Lines = append(s.Lines, Normalize(n.Command))
+	}
+	return &c
+}
+
+// Entries gets the commands in a section
+func (d *Document) Entries(path string) []Entry {
+	var out []Entry
+	for _, n := range d.Nodes {
+		if n.Path == path && n.Command != "" {
+			out = append(out, n.Entry())
+		}
+	}
+	return out
+}
+
+// Remove deletes the commands in a section for which match returns true, and
+// returns the number of commands that were removed
+func (d *Document) Remove(path string, match func(Entry) bool) int {
+	var keep []*Node
+	var removed int
+	for _, n := range d.Nodes {
+		if n.Path == path && n.Command != "" && match(n.Entry()) {
+			removed++
+			continue
+		}
+		keep = append(keep, n)
+	}
+	d.Nodes = keep
+	return removed
+}
+
+// Append adds a command after the last command in a section, formatted as
+// "/export" would format it. The section is added at the end of the document
+// if it does not exist yet.
+func (d *Document) Append(path string, e Entry) {
+	cmd := e.String()
+	n := &Node{Path: path, Command: cmd, Text: Wrap(cmd, ExportWidth)}
+
+	last := -1
+	for i, m := range d.Nodes {
+		if m.Path == path && (m.Header || m.Command != "") {
+			last = i
+		}
+	}
+	if last < 0 {
+		d.Nodes = append(d.Nodes, &Node{Path: path, Header: true, Text: []string{path}}, n)
+		return
+	}
+
+	d.Nodes = append(d.Nodes, nil)
+	copy(d.Nodes[last+2:], d.Nodes[last+1:])
+	d.Nodes[last+1] = n
+}
+
+// WriteTo writes the document with unix line endings
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, n := range d.Nodes {
+		for _, line := range n.Text {
+			k, err := io.WriteString(w, line+"\n")
+			total += int64(k)
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,134 @@
+package rsc
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+const (
+	StaticDNSPath = "/ip dns static"
+	LeasePath     = "/ip dhcp-server lease"
+)
+
+// StaticDNS is an entry under "/ip dns static"
+type StaticDNS struct {
+	Name    string
+	Address netip.Addr
+	Comment string
+}
+
+// Lease is a reserved address under "/ip dhcp-server lease"
+type Lease struct {
+	Address  netip.Addr
+	MAC      string // upper case with colons, as in the export
+	ClientID string
+	Server   string
+	Comment  string
+}
+
+// ParseStaticDNS converts a command from "/ip dns static" to a record
+func ParseStaticDNS(e Entry) (StaticDNS, error) {
+	var r StaticDNS
+	var err error
+	r.Name, _ = e.Get("name")
+	r.Comment, _ = e.Get("comment")
+	if v, ok := e.Get("address"); ok {
+		r.Address, err = netip.ParseAddr(v)
+		if err != nil {
+			return r, fmt.Errorf("error parsing address for %s: %w", r.Name, err)
+		}
+	}
+	return r, nil
+}
+
+// Entry converts the record to a command, with properties in the order that
+// "/export" uses
+func (r StaticDNS) Entry() Entry {
+	e := Entry{Command: "add"}
+	e.Props = append(e.Props, Prop{"address", r.Address.String()})
+	if r.Comment != "" {
+		e.Props = append(e.Props, Prop{"comment", r.Comment})
+	}
+	e.Props = append(e.Props, Prop{"name", r.Name})
+	return e
+}
+
+// ParseLease converts a command from "/ip dhcp-server lease" to a record
+func ParseLease(e Entry) (Lease, error) {
+	var r Lease
+	var err error
+	r.ClientID, _ = e.Get("client-id")
+	r.Server, _ = e.Get("server")
+	r.Comment, _ = e.Get("comment")
+	if v, ok := e.Get("mac-address"); ok {
+		r.MAC, err = ParseMAC(v)
+		if err != nil {
+			return r, err
+		}
+	}
+	if v, ok := e.Get("address"); ok {
+		r.Address, err = netip.ParseAddr(v)
+		if err != nil {
+			return r, fmt.Errorf("error parsing address for %s: %w", r.MAC, err)
+		}
+	}
+	return r, nil
+}
+
+// Entry converts the record to a command, with properties in the order that
+// "/export" uses
+func (r Lease) Entry() Entry {
+	e := Entry{Command: "add"}
+	e.Props = append(e.Props, Prop{"address", r.Address.String()})
+	if r.ClientID != "" {
+		e.Props = append(e.Props, Prop{"client-id", r.ClientID})
+	}
+	if r.Comment != "" {
+		e.Props = append(e.Props, Prop{"comment", r.Comment})
+	}
+	e.Props = append(e.Props, Prop{"mac-address", r.MAC})
+	if r.Server != "" {
+		e.Props = append(e.Props, Prop{"server", r.Server})
+	}
+	return e
+}
+
+// ParseMAC parses a MAC address into the upper case form used by RouterOS
+func ParseMAC(s string) (string, error) {
+	hw, err := net.ParseMAC(s)
+	if err != nil {
+		return "", err
+	}
+	if len(hw) != 6 {
+		return "", fmt.Errorf("%s is not an ethernet address", s)
+	}
+	return strings.ToUpper(hw.String()), nil
+}
+
+// StaticDNS gets the entries under "/ip dns static"
+func (d *Document) StaticDNS() ([]StaticDNS, error) {
+	var out []StaticDNS
+	for _, e := range d.Entries(StaticDNSPath) {
+		r, err := ParseStaticDNS(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// Leases gets the entries under "/ip dhcp-server lease"
+func (d *Document) Leases() ([]Lease, error) {
+	var out []Lease
+	for _, e := range d.Entries(LeasePath) {
+		r, err := ParseLease(e)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
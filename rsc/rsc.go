@@ -0,0 +1,196 @@
+// Package rsc parses RouterOS configuration exports such as router/router.rsc
+package rsc
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// Config is a parsed RouterOS export
+type Config struct {
+	Sections []*Section
+}
+
+// Section is a block of commands under a path such as "/ip dns static"
+type Section struct {
+	Path  string
+	Lines []string // complete commands with line continuations joined
+}
+
+// Section gets the section with the given path, or nil if there is none
+func (c *Config) Section(path string) *Section {
+	for _, s := range c.Sections {
+		if s.Path == path {
+			return s
+		}
+	}
+	return nil
+}
+
+// Parse reads an export. Comments, including the timestamp header, are dropped,
+// line continuations are joined, and CRLF line endings are accepted. A path that
+// appears more than once is merged into a single section.
+func Parse(r io.Reader) (*Config, error) {
+	d, err := ParseDocument(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.Config(), nil
+}
+
+// Fields splits a command into words, keeping quoted strings and bracketed
+// expressions such as [ find default=yes ] together
+func Fields(line string) []string {
+	var out []string
+	var cur strings.Builder
+	var quoted bool
+	var depth int
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(line):
+			cur.WriteByte(c)
+			i++
+			cur.WriteByte(line[i])
+			continue
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == '[':
+			depth++
+		case !quoted && c == ']' && depth > 0:
+			depth--
+		case !quoted && depth == 0 && c == ' ':
+			if cur.Len() > 0 {
+				out = append(out, cur.String())
+				cur.Reset()
+			}
+			continue
+		}
+		cur.WriteByte(c)
+	}
+	if cur.Len() > 0 {
+		out = append(out, cur.String())
+	}
+	return out
+}
+
+// Entry is a single command such as "add address=192.168.88.1 name=router.lan"
+type Entry struct {
+	Command    string   // e.g. "add" or "set"
+	Positional []string // arguments without a key, e.g. "0" in "set 0 default-vlan-id=0"
+	Props      []Prop   // key=value arguments in the order they appeared
+}
+
+// Prop is a key=value argument
+type Prop struct {
+	Key   string
+	Value string // quotes are removed
+}
+
+// ParseEntry parses a single command
+func ParseEntry(line string) Entry {
+	var e Entry
+	for i, f := range Fields(line) {
+		if i == 0 {
+			e.Command = f
+			continue
+		}
+		k, v, ok := strings.Cut(f, "=")
+		if !ok || strings.HasPrefix(f, "[") {
+			e.Positional = append(e.Positional, f)
+			continue
+		}
+		e.Props = append(e.Props, Prop{Key: k, Value: unquote(v)})
+	}
+	return e
+}
+
+// Get gets the value for a key
+func (e Entry) Get(key string) (string, bool) {
+	for _, p := range e.Props {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// String formats the entry as a command, quoting values where needed
+func (e Entry) String() string {
+	parts := []string{e.Command}
+	parts = append(parts, e.Positional...)
+	for _, p := range e.Props {
+		parts = append(parts, p.Key+"="+quote(p.Value))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Normalize puts a command into a canonical form with its key=value arguments
+// sorted, so that two commands that differ only in argument order are equal
+func Normalize(line string) string {
+	e := ParseEntry(line)
+	sort.SliceStable(e.Props, func(i, j int) bool {
+		return e.Props[i].Key < e.Props[j].Key
+	})
+	return e.String()
+}
+
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	var b strings.Builder
+	s = s[1 : len(s)-1]
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// quote formats a value as a RouterOS string literal if it needs quoting
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \"\\;$?[]{}\n\r\t") {
+		return s
+	}
+	return Quote(s)
+}
+
+// Quote formats a value as a RouterOS string literal. Quotes and backslashes
+// are escaped so that the value cannot end the string, and "$" and "?" are
+// escaped so that the script parser does not expand variables or print help.
+func Quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"', '\\', '$', '?':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
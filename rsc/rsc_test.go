@@ -0,0 +1,32 @@
+package rsc
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	cases := []struct {
+		in   string
+		want string
+	}{
+		{`laptop`, `"laptop"`},
+		{`a b`, `"a b"`},
+		{`x"; /system reboot; :put "`, `"x\"; /system reboot; :put \""`},
+		{`$password`, `"\$password"`},
+		{`back\slash`, `"back\\slash"`},
+		{"two\nlines", `"two\nlines"`},
+	}
+	for _, c := range cases {
+		if got := Quote(c.in); got != c.want {
+			t.Errorf("Quote(%q) = %s, want %s", c.in, got, c.want)
+		}
+	}
+}
+
+func TestQuoteRoundTrip(t *testing.T) {
+	for _, name := range []string{`plain`, `with space`, `q"uote`, `semi;colon`, `$var`, `[find]`, `a\b`, "tab\there"} {
+		e := Entry{Command: "add", Props: []Prop{{Key: "name", Value: name}}}
+		got, ok := ParseEntry(e.String()).Get("name")
+		if !ok || got != name {
+			t.Errorf("round trip of %q through %s gave %q", name, e.String(), got)
+		}
+	}
+}
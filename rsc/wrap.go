@@ -0,0 +1,41 @@
+package rsc
+
+import "strings"
+
+// ExportWidth is the line width used by "/export" on RouterOS
+const ExportWidth = 80
+
+// Wrap breaks a command into lines the way "/export" does: lines end with a
+// backslash when the command continues, continuation lines are indented by four
+// spaces, and a property that does not fit is broken after its "=" when the key
+// still fits on the current line.
+func Wrap(line string, width int) []string {
+	var out []string
+	var cur strings.Builder
+	start := true // whether cur has nothing after its indentation
+	for _, f := range Fields(line) {
+		sep := " "
+		if start {
+			sep = ""
+		}
+
+		// leave room for " \" after a whole property, or "\" after a key, and
+		// for a two character margin as RouterOS does
+		switch {
+		case start || cur.Len()+len(sep)+len(f)+2 <= width-2:
+			cur.WriteString(sep + f)
+		case strings.Contains(f, "=") && !strings.HasPrefix(f, "[") &&
+			cur.Len()+len(sep)+strings.Index(f, "=")+2 <= width-2:
+			k, v, _ := strings.Cut(f, "=")
+			out = append(out, cur.String()+sep+k+"=\\")
+			cur.Reset()
+			cur.WriteString("    " + v)
+		default:
+			out = append(out, cur.String()+" \\")
+			cur.Reset()
+			cur.WriteString("    " + f)
+		}
+		start = false
+	}
+	return append(out, cur.String())
+}
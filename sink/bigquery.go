@@ -0,0 +1,215 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	storage "cloud.google.com/go/bigquery/storage/apiv1beta2"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	storagepb "google.golang.org/genproto/googleapis/cloud/bigquery/storage/v1beta2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// MaxPendingRows is the max number of rows to keep for retrying after bigquery errors
+const MaxPendingRows = 10000
+
+// appendStream is the part of BigQueryWrite_AppendRowsClient that we use
+type appendStream interface {
+	Send(*storagepb.AppendRowsRequest) error
+	Recv() (*storagepb.AppendRowsResponse, error)
+	CloseSend() error
+}
+
+// BigQuery writes rows to a bigquery write stream. It keeps one append stream
+// open across writes and opens a new one only after an error.
+//
+// Each append carries the offset of its first row in the write stream, so that
+// bigquery refuses a retried append that in fact went through. Rows that fail
+// to send for a reason that may pass, such as a dropped connection, are kept
+// and retried in the same batches with the same offsets, up to MaxPendingRows.
+// Rows that bigquery rejects as invalid are logged and dropped, since sending
+// them again would fail the same way.
+type BigQuery struct {
+	descriptor  *descriptorpb.DescriptorProto               // the protobuf descriptor for bigquery
+	writeStream string                                      // the name of the bigquery write stream
+	traceID     string                                      // identifies this client in bigquery debug logs
+	open        func(context.Context) (appendStream, error) // opens an append stream
+	stream      appendStream                                // open append stream, or nil after an error
+	offset      int64                                       // number of rows appended to the write stream
+	pending     [][][]byte                                  // batches of serialized rows that failed to send
+}
+
+// NewBigQuery creates a write stream for the given table. The rows written to
+// the sink must all be of the same type as msg.
+func NewBigQuery(ctx context.Context, client *storage.BigQueryWriteClient, project, dataset, table string, msg proto.Message, traceID string) (*BigQuery, error) {
+	// create the write stream
+	parent := fmt.Sprintf("projects/%s/datasets/%s/tables/%s", project, dataset, table)
+	resp, err := client.CreateWriteStream(ctx, &storagepb.CreateWriteStreamRequest{
+		Parent: parent,
+		WriteStream: &storagepb.WriteStream{
+			Type: storagepb.WriteStream_COMMITTED,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("CreateWriteStream for %s: %w", table, err)
+	}
+
+	// get descriptor for our protobuf representing a bigquery row
+	descriptor, err := adapt.NormalizeDescriptor(msg.ProtoReflect().Descriptor())
+	if err != nil {
+		return nil, fmt.Errorf("NormalizeDescriptor: %w", err)
+	}
+
+	return &BigQuery{
+		descriptor:  descriptor,
+		writeStream: resp.Name,
+		traceID:     traceID,
+		open: func(ctx context.Context) (appendStream, error) {
+			return client.AppendRows(ctx)
+		},
+	}, nil
+}
+
+func (s *BigQuery) Write(ctx context.Context, msgs []proto.Message) error {
+	// initialize options for protobuf marshalling
+	var protoMarshal proto.MarshalOptions
+
+	// serialize the new rows before touching the pending ones, so that a row
+	// that cannot be serialized does not lose the rows waiting for a retry
+	var rows [][]byte
+	for _, msg := range msgs {
+		buf, err := protoMarshal.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("protobuf.Marshal: %w", err)
+		}
+		rows = append(rows, buf)
+	}
+	if len(rows) > 0 {
+		s.pending = append(s.pending, rows)
+	}
+
+	// send the batches in order, starting with any that failed last time
+	var sent, dropped int
+	var rejected error
+	for len(s.pending) > 0 {
+		batch := s.pending[0]
+		retry, err := s.send(ctx, batch)
+		if err != nil && retry {
+			s.trimPending()
+			log.Printf("will retry %d rows on the next write", s.pendingRows())
+			return err
+		}
+		if err != nil {
+			log.Printf("dropping %d rows that bigquery rejected: %v", len(batch), err)
+			dropped += len(batch)
+			if rejected == nil {
+				rejected = err
+			}
+		} else {
+			s.offset += int64(len(batch))
+			sent += len(batch)
+		}
+		s.pending = s.pending[1:]
+	}
+
+	log.Printf("sent %d rows to bigquery", sent)
+	if rejected != nil {
+		return fmt.Errorf("dropped %d rows: %w", dropped, rejected)
+	}
+	return nil
+}
+
+// pendingRows gets the number of rows waiting to be retried
+func (s *BigQuery) pendingRows() int {
+	var n int
+	for _, batch := range s.pending {
+		n += len(batch)
+	}
+	return n
+}
+
+// trimPending drops the oldest batches until at most MaxPendingRows are left.
+// Whole batches are dropped so that the batches that are left are retried
+// exactly as they were sent.
+func (s *BigQuery) trimPending() {
+	for len(s.pending) > 1 && s.pendingRows() > MaxPendingRows {
+		s.pending = s.pending[1:]
+	}
+	if batch := s.pending[0]; len(batch) > MaxPendingRows {
+		s.pending[0] = batch[len(batch)-MaxPendingRows:]
+	}
+}
+
+// send appends one batch of rows at the current offset. It reports whether a
+// failure is worth retrying: bigquery rejecting the rows as invalid is not,
+// while anything else, such as the connection dropping, may pass. A batch that
+// bigquery already has from an earlier attempt counts as sent.
+func (s *BigQuery) send(ctx context.Context, rows [][]byte) (retry bool, err error) {
+	// open the stream for pushing data to bigquery, or re-use the one from the
+	// previous write so that we do not leak a stream per write
+	if s.stream == nil {
+		stream, err := s.open(ctx)
+		if err != nil {
+			return true, fmt.Errorf("AppendRows: %w", err)
+		}
+		s.stream = stream
+	}
+
+	// push the data to bigquery
+	err = s.stream.Send(&storagepb.AppendRowsRequest{
+		WriteStream: s.writeStream,
+		Offset:      wrapperspb.Int64(s.offset),
+		TraceId:     s.traceID,
+		Rows: &storagepb.AppendRowsRequest_ProtoRows{
+			ProtoRows: &storagepb.AppendRowsRequest_ProtoData{
+				WriterSchema: &storagepb.ProtoSchema{
+					ProtoDescriptor: s.descriptor,
+				},
+				Rows: &storagepb.ProtoRows{
+					SerializedRows: rows, // serialized protocol buffer data
+				},
+			},
+		},
+	})
+	if err != nil {
+		s.Close()
+		return retryable(status.Code(err)), fmt.Errorf("AppendRows.Send: %w", err)
+	}
+
+	// get the response
+	resp, err := s.stream.Recv()
+	if err != nil {
+		s.Close()
+		return retryable(status.Code(err)), fmt.Errorf("AppendRows.Recv: %w", err)
+	}
+	if st := resp.GetError(); st != nil {
+		code := codes.Code(st.GetCode())
+		if code == codes.AlreadyExists {
+			return false, nil
+		}
+		s.Close()
+		return retryable(code), fmt.Errorf("AppendRows: %s: %s", code, st.GetMessage())
+	}
+	return false, nil
+}
+
+// retryable reports whether an append that failed with the given code may
+// succeed if it is sent again
+func retryable(code codes.Code) bool {
+	return code != codes.InvalidArgument
+}
+
+// Close closes the append stream if one is open. The next write opens a new one.
+func (s *BigQuery) Close() error {
+	if s.stream == nil {
+		return nil
+	}
+	err := s.stream.CloseSend()
+	s.stream = nil
+	return err
+}
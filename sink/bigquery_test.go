@@ -0,0 +1,298 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	storagepb "google.golang.org/genproto/googleapis/cloud/bigquery/storage/v1beta2"
+	statuspb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeTable is a bigquery write stream that checks offsets as bigquery does.
+// Its append streams fail in the ways set by the test.
+type fakeTable struct {
+	rows    [][]byte                     // rows appended so far
+	offsets []int64                      // offset of each append request
+	streams []*fakeStream                // append streams opened so far
+	sendErr error                        // if set then Send fails
+	lostAck bool                         // if set then Recv fails after appending the rows
+	reject  *statuspb.Status             // if set then the response is this error
+	last    *storagepb.AppendRowsRequest // the request waiting for a response
+}
+
+func (f *fakeTable) open(ctx context.Context) (appendStream, error) {
+	s := &fakeStream{table: f}
+	f.streams = append(f.streams, s)
+	return s, nil
+}
+
+type fakeStream struct {
+	table  *fakeTable
+	closed bool
+}
+
+func (s *fakeStream) Send(req *storagepb.AppendRowsRequest) error {
+	if s.table.sendErr != nil {
+		return s.table.sendErr
+	}
+	s.table.last = req
+	return nil
+}
+
+func (s *fakeStream) Recv() (*storagepb.AppendRowsResponse, error) {
+	f := s.table
+	req := f.last
+	f.offsets = append(f.offsets, req.GetOffset().GetValue())
+	if f.reject != nil {
+		return &storagepb.AppendRowsResponse{Response: &storagepb.AppendRowsResponse_Error{Error: f.reject}}, nil
+	}
+
+	offset := req.GetOffset().GetValue()
+	switch {
+	case offset < int64(len(f.rows)):
+		return errorResponse(codes.AlreadyExists, "offset already exists"), nil
+	case offset > int64(len(f.rows)):
+		return errorResponse(codes.OutOfRange, "offset beyond the end of the stream"), nil
+	}
+	f.rows = append(f.rows, req.GetProtoRows().GetRows().GetSerializedRows()...)
+	if f.lostAck {
+		return nil, status.Error(codes.Unavailable, "connection reset")
+	}
+	return &storagepb.AppendRowsResponse{
+		Response: &storagepb.AppendRowsResponse_AppendResult_{
+			AppendResult: &storagepb.AppendRowsResponse_AppendResult{Offset: wrapperspb.Int64(offset)},
+		},
+	}, nil
+}
+
+func (s *fakeStream) CloseSend() error {
+	s.closed = true
+	return nil
+}
+
+func errorResponse(code codes.Code, msg string) *storagepb.AppendRowsResponse {
+	return &storagepb.AppendRowsResponse{
+		Response: &storagepb.AppendRowsResponse_Error{Error: &statuspb.Status{Code: int32(code), Message: msg}},
+	}
+}
+
+func rows(values ...string) []proto.Message {
+	var out []proto.Message
+	for _, v := range values {
+		out = append(out, wrapperspb.String(v))
+	}
+	return out
+}
+
+func decode(t *testing.T, sent [][]byte) []string {
+	t.Helper()
+	var out []string
+	for _, buf := range sent {
+		var v wrapperspb.StringValue
+		if err := proto.Unmarshal(buf, &v); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, v.Value)
+	}
+	return out
+}
+
+// pending gets the values of the rows waiting to be retried
+func pending(t *testing.T, s *BigQuery) []string {
+	t.Helper()
+	var out []string
+	for _, batch := range s.pending {
+		out = append(out, decode(t, batch)...)
+	}
+	return out
+}
+
+func TestBigQueryReusesStream(t *testing.T) {
+	var table fakeTable
+	s := &BigQuery{open: table.open}
+
+	for i := 0; i < 3; i++ {
+		if err := s.Write(context.Background(), rows("a", "b")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(table.streams) != 1 {
+		t.Errorf("expected one stream across writes, got %d", len(table.streams))
+	}
+	if len(table.rows) != 6 {
+		t.Errorf("expected 6 rows to be appended, got %d", len(table.rows))
+	}
+	if want := []int64{0, 2, 4}; !reflect.DeepEqual(table.offsets, want) {
+		t.Errorf("expected offsets %v, got %v", want, table.offsets)
+	}
+}
+
+func TestBigQueryRetriesPendingRows(t *testing.T) {
+	var table fakeTable
+	s := &BigQuery{open: table.open}
+	ctx := context.Background()
+
+	if err := s.Write(ctx, rows("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed write keeps the rows and closes the stream
+	table.sendErr = status.Error(codes.Unavailable, "unavailable")
+	if err := s.Write(ctx, rows("second")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if !table.streams[0].closed {
+		t.Error("expected the stream to be closed after an error")
+	}
+	if err := s.Write(ctx, rows("third")); err == nil {
+		t.Fatal("expected an error")
+	}
+	if got := strings.Join(pending(t, s), ","); got != "second,third" {
+		t.Errorf("expected second,third to be pending, got %s", got)
+	}
+
+	// the next write opens a new stream and sends the pending rows first
+	table.sendErr = nil
+	if err := s.Write(ctx, rows("fourth")); err != nil {
+		t.Fatal(err)
+	}
+	if len(table.streams) != 3 {
+		t.Errorf("expected a new stream for each write after an error, got %d streams", len(table.streams))
+	}
+	if got := strings.Join(decode(t, table.rows), ","); got != "first,second,third,fourth" {
+		t.Errorf("expected first,second,third,fourth to be appended, got %s", got)
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("expected nothing pending, got %d batches", len(s.pending))
+	}
+}
+
+// When the append goes through but the response is lost, the retry carries
+// the same offset and bigquery refuses it, so the rows are not appended twice
+func TestBigQueryRetryAfterLostResponse(t *testing.T) {
+	var table fakeTable
+	s := &BigQuery{open: table.open}
+	ctx := context.Background()
+
+	if err := s.Write(ctx, rows("first")); err != nil {
+		t.Fatal(err)
+	}
+
+	table.lostAck = true
+	if err := s.Write(ctx, rows("second", "third")); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	table.lostAck = false
+	if err := s.Write(ctx, rows("fourth")); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(decode(t, table.rows), ","); got != "first,second,third,fourth" {
+		t.Errorf("expected each row to be appended once, got %s", got)
+	}
+	if want := []int64{0, 1, 1, 3}; !reflect.DeepEqual(table.offsets, want) {
+		t.Errorf("expected offsets %v, got %v", want, table.offsets)
+	}
+}
+
+func TestBigQueryDropsRejectedRows(t *testing.T) {
+	var table fakeTable
+	s := &BigQuery{open: table.open}
+	ctx := context.Background()
+
+	table.reject = &statuspb.Status{Code: int32(codes.InvalidArgument), Message: "schema mismatch"}
+	err := s.Write(ctx, rows("bad"))
+	if err == nil || !strings.Contains(err.Error(), "schema mismatch") {
+		t.Fatalf("expected the response error, got %v", err)
+	}
+	if len(s.pending) != 0 {
+		t.Errorf("expected the rejected row to be dropped, got %q pending", pending(t, s))
+	}
+
+	// the rows after it are sent at the offset the rejected rows would have had
+	table.reject = nil
+	if err := s.Write(ctx, rows("good")); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(decode(t, table.rows), ","); got != "good" {
+		t.Errorf("expected only the good row to be appended, got %s", got)
+	}
+}
+
+func TestBigQueryKeepsRowsAfterOtherErrors(t *testing.T) {
+	for _, code := range []codes.Code{codes.Unavailable, codes.Internal, codes.ResourceExhausted} {
+		var table fakeTable
+		s := &BigQuery{open: table.open}
+		table.reject = &statuspb.Status{Code: int32(code), Message: "try again"}
+		if err := s.Write(context.Background(), rows("a")); err == nil {
+			t.Fatalf("%s: expected an error", code)
+		}
+		if got := pending(t, s); len(got) != 1 {
+			t.Errorf("%s: expected the row to be kept for retry, got %q pending", code, got)
+		}
+	}
+}
+
+func TestBigQueryMarshalErrorKeepsPendingRows(t *testing.T) {
+	var table fakeTable
+	s := &BigQuery{open: table.open}
+	ctx := context.Background()
+
+	table.sendErr = errors.New("connection refused")
+	if err := s.Write(ctx, rows("first")); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// a string that is not valid UTF-8 cannot be marshalled
+	if err := s.Write(ctx, rows("second", "\xff")); err == nil {
+		t.Fatal("expected a marshal error")
+	}
+	if got := strings.Join(pending(t, s), ","); got != "first" {
+		t.Errorf("expected the pending row to be kept, got %s", got)
+	}
+}
+
+func TestBigQueryDropsOldestPendingRows(t *testing.T) {
+	table := fakeTable{sendErr: errors.New("unavailable")}
+	s := &BigQuery{open: table.open}
+
+	var many []string
+	for i := 0; i < MaxPendingRows; i++ {
+		many = append(many, "old")
+	}
+	s.Write(context.Background(), rows(many...))
+	if n := s.pendingRows(); n != MaxPendingRows {
+		t.Fatalf("expected %d pending rows, got %d", MaxPendingRows, n)
+	}
+
+	// the oldest batch is dropped whole, so that the rest keep their offsets
+	s.Write(context.Background(), rows("new"))
+	if got := pending(t, s); len(got) != 1 || got[0] != "new" {
+		t.Errorf("expected only the newest row to be kept, got %d rows", len(got))
+	}
+
+	// a single batch that is too big keeps its newest rows
+	s.Write(context.Background(), rows(append(many, "newest")...))
+	if got := pending(t, s); len(got) != MaxPendingRows || got[len(got)-1] != "newest" {
+		t.Errorf("expected the newest %d rows to be kept, got %d", MaxPendingRows, len(got))
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var b bytes.Buffer
+	s := Print{W: &b}
+	if err := s.Write(context.Background(), rows("a", "b")); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "\"a\"\n\"b\"\n" {
+		t.Errorf("unexpected output %q", got)
+	}
+}
@@ -0,0 +1,35 @@
+// Package sink writes rows to bigquery, or prints them for offline runs
+package sink
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Sink is somewhere that rows are written to
+type Sink interface {
+	Write(ctx context.Context, rows []proto.Message) error
+}
+
+// Print writes rows as JSON, one per line
+type Print struct {
+	W io.Writer
+}
+
+func (s *Print) Write(ctx context.Context, msgs []proto.Message) error {
+	for _, msg := range msgs {
+		buf, err := protojson.Marshal(msg)
+		if err != nil {
+			return fmt.Errorf("protojson.Marshal: %w", err)
+		}
+		_, err = fmt.Fprintf(s.W, "%s\n", buf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
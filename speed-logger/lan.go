@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The LAN test measures throughput to a speed-logger server on the local
+// network, so that slow Wi-Fi can be told apart from a slow uplink. Each TCP
+// connection to the server begins with one of these lines:
+//
+//	ping      the server echoes back every line that it receives
+//	download  the server sends data until the client closes the connection
+//	upload    the server discards data until the client closes its side of the
+//	          connection, and then replies "<bytes> <microseconds>"
+const (
+	lanBufferSize    = 128 << 10              // size of each read and write
+	lanPingInterval  = 100 * time.Millisecond // time between pings while under load
+	lanMaxConnection = 10 * time.Minute       // the server drops connections older than this
+	defaultLANPort   = 5201
+)
+
+// serveLAN accepts LAN test connections until the listener fails
+func serveLAN(listener net.Listener) error {
+	log.Println("serving lan speed tests on", listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go handleLAN(conn)
+	}
+}
+
+// handleLAN serves one LAN test connection
+func handleLAN(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(lanMaxConnection))
+
+	r := bufio.NewReaderSize(conn, lanBufferSize)
+	test, err := r.ReadString('\n')
+	if err != nil {
+		log.Printf("error reading test from %v: %v", conn.RemoteAddr(), err)
+		return
+	}
+
+	switch strings.TrimSpace(test) {
+	case "ping":
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			_, err = io.WriteString(conn, line)
+			if err != nil {
+				return
+			}
+		}
+
+	case "download":
+		buf := make([]byte, lanBufferSize)
+		for {
+			_, err := conn.Write(buf)
+			if err != nil {
+				return
+			}
+		}
+
+	case "upload":
+		// time from the first byte to the end of the upload, so that the
+		// client's connection setup is not counted
+		_, err := r.Peek(1)
+		if err != nil {
+			log.Printf("error waiting for upload from %v: %v", conn.RemoteAddr(), err)
+			return
+		}
+		begin := time.Now()
+		n, err := io.Copy(io.Discard, r)
+		if err != nil {
+			log.Printf("error receiving upload from %v: %v", conn.RemoteAddr(), err)
+			return
+		}
+		fmt.Fprintf(conn, "%d %d\n", n, time.Since(begin).Microseconds())
+
+	default:
+		log.Printf("unknown test %q from %v", test, conn.RemoteAddr())
+	}
+}
+
+// dialLAN connects to a LAN test server and starts the given test
+func dialLAN(ctx context.Context, addr, test string) (net.Conn, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(conn, test+"\n")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// pinger measures round trips over a "ping" connection
+type pinger struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialPinger(ctx context.Context, addr string) (*pinger, error) {
+	conn, err := dialLAN(ctx, addr, "ping")
+	if err != nil {
+		return nil, err
+	}
+	return &pinger{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (p *pinger) Close() error {
+	return p.conn.Close()
+}
+
+// ping gets the latency to the server, which like the speedtest.net latency is
+// half of the round trip time
+func (p *pinger) ping(timeout time.Duration) (time.Duration, error) {
+	p.conn.SetDeadline(time.Now().Add(timeout))
+	begin := time.Now()
+	_, err := io.WriteString(p.conn, "ping\n")
+	if err != nil {
+		return 0, err
+	}
+	_, err = p.r.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(begin) / 2, nil
+}
+
+// pingUntil pings the server every lanPingInterval until done is closed, and
+// returns the median latency
+func (p *pinger) pingUntil(done <-chan struct{}, timeout time.Duration) (time.Duration, error) {
+	var samples []time.Duration
+	ticker := time.NewTicker(lanPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			if len(samples) == 0 {
+				return 0, errors.New("no pings completed under load")
+			}
+			sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+			return samples[len(samples)/2], nil
+		case <-ticker.C:
+			latency, err := p.ping(timeout)
+			if err != nil {
+				return 0, err
+			}
+			samples = append(samples, latency)
+		}
+	}
+}
+
+// isTimeout checks whether an error came from a deadline passing
+func isTimeout(err error) bool {
+	return errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// downloadStream reads from a "download" connection until the deadline, and
+// stores the number of bytes received
+func downloadStream(conn net.Conn, deadline time.Time, n *int64, errp *error, wg *sync.WaitGroup) {
+	defer wg.Done()
+	conn.SetReadDeadline(deadline)
+	*n, *errp = io.CopyBuffer(io.Discard, conn, make([]byte, lanBufferSize))
+	if isTimeout(*errp) {
+		*errp = nil
+	}
+}
+
+// uploadStream writes to an "upload" connection until the deadline, and
+// stores the throughput in megabits per second as measured by the server
+func uploadStream(conn net.Conn, deadline time.Time, timeout time.Duration, mbps *float64, errp *error, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	buf := make([]byte, lanBufferSize)
+	conn.SetWriteDeadline(deadline)
+	for {
+		_, err := conn.Write(buf)
+		if isTimeout(err) {
+			break
+		}
+		if err != nil {
+			*errp = err
+			return
+		}
+	}
+
+	// tell the server that the upload has finished and wait for its count
+	conn.SetDeadline(time.Now().Add(timeout))
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		*errp = errors.New("upload connection is not a TCP connection")
+		return
+	}
+	err := tcp.CloseWrite()
+	if err != nil {
+		*errp = err
+		return
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		*errp = fmt.Errorf("error reading upload result: %w", err)
+		return
+	}
+	var bytes, micros int64
+	_, err = fmt.Sscanf(reply, "%d %d", &bytes, &micros)
+	if err != nil || micros <= 0 {
+		*errp = fmt.Errorf("unexpected upload result %q", strings.TrimSpace(reply))
+		return
+	}
+	*mbps = float64(bytes) * 8 / float64(micros)
+}
+
+// measureLAN runs a download or upload test over several streams at once,
+// pinging the server in the meantime. It returns the total throughput in
+// megabits per second and the median latency under load.
+func measureLAN(ctx context.Context, addr, test string, streams int, duration, timeout time.Duration) (float64, time.Duration, error) {
+	p, err := dialPinger(ctx, addr)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer p.Close()
+
+	var conns []net.Conn
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for i := 0; i < streams; i++ {
+		conn, err := dialLAN(ctx, addr, test)
+		if err != nil {
+			return 0, 0, err
+		}
+		conns = append(conns, conn)
+	}
+
+	// run the streams and the pinger until the deadline
+	begin := time.Now()
+	deadline := begin.Add(duration)
+	counts := make([]int64, streams)
+	rates := make([]float64, streams)
+	errs := make([]error, streams)
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		wg.Add(1)
+		if test == "download" {
+			go downloadStream(conn, deadline, &counts[i], &errs[i], &wg)
+		} else {
+			go uploadStream(conn, deadline, timeout, &rates[i], &errs[i], &wg)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	loaded, pingErr := p.pingUntil(done, timeout)
+	<-done
+
+	for _, err := range errs {
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	var mbps float64
+	if test == "download" {
+		var total int64
+		for _, n := range counts {
+			total += n
+		}
+		mbps = float64(total) * 8 / float64(time.Since(begin).Microseconds())
+	} else {
+		for _, rate := range rates {
+			mbps += rate
+		}
+	}
+	if pingErr != nil {
+		return mbps, 0, fmt.Errorf("ping under load: %w", pingErr)
+	}
+	return mbps, loaded, nil
+}
+
+// runLANTest measures the latency, jitter, download and upload speeds to a
+// LAN test server, and fills in the results in row. Failed tests are logged,
+// left as zero, and recorded in the Error field.
+func runLANTest(ctx context.Context, addr string, streams int, duration, timeout time.Duration, row *SpeedResult) {
+	row.Test = "lan"
+	row.Server = addr
+	row.Streams = int64(streams)
+
+	log.Printf("running a lan speed test against %s with %d streams...", addr, streams)
+
+	var errs []string
+	fail := func(test string, err error) {
+		log.Printf("%s test failed: %v", test, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", test, err))
+	}
+
+	// do the ping test while the network is idle
+	p, err := dialPinger(ctx, addr)
+	if err != nil {
+		fail("ping", err)
+		row.Error = strings.Join(errs, "; ")
+		return
+	}
+	var samples []time.Duration
+	for i := 0; i < pingCount; i++ {
+		latency, err := p.ping(timeout)
+		if err != nil {
+			fail("ping", err)
+			break
+		}
+		samples = append(samples, latency)
+	}
+	p.Close()
+	if len(samples) == pingCount {
+		latency, jitter := summarizePings(samples)
+		row.Latency = latency.Milliseconds()
+		row.Jitter = float64(jitter.Microseconds()) / 1000
+	}
+
+	// do the download and upload tests, and report the worse of the two
+	// latencies under load
+	down, downLoaded, err := measureLAN(ctx, addr, "download", streams, duration, timeout)
+	if err != nil {
+		fail("download", err)
+	}
+	up, upLoaded, err := measureLAN(ctx, addr, "upload", streams, duration, timeout)
+	if err != nil {
+		fail("upload", err)
+	}
+	row.Down = math.Round(down*100) / 100
+	row.Up = math.Round(up*100) / 100
+	if upLoaded > downLoaded {
+		downLoaded = upLoaded
+	}
+	row.LoadedLatency = downLoaded.Milliseconds()
+
+	row.Error = strings.Join(errs, "; ")
+}
+
+// parseLANAddr adds the default port to a LAN test server address if it has none
+func parseLANAddr(addr string) string {
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(addr, strconv.Itoa(defaultLANPort))
+}
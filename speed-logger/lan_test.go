@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// startLANServer serves LAN tests on a random localhost port and returns the
+// address
+func startLANServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go serveLAN(listener)
+	return listener.Addr().String()
+}
+
+func TestMeasureLAN(t *testing.T) {
+	addr := startLANServer(t)
+	for _, test := range []string{"download", "upload"} {
+		mbps, loaded, err := measureLAN(context.Background(), addr, test, 2, 500*time.Millisecond, 5*time.Second)
+		if err != nil {
+			t.Errorf("%s: %v", test, err)
+			continue
+		}
+		if mbps <= 0 {
+			t.Errorf("%s: expected a positive throughput, got %v", test, mbps)
+		}
+		if loaded <= 0 || loaded > time.Second {
+			t.Errorf("%s: expected a latency under load between 0 and 1s, got %v", test, loaded)
+		}
+	}
+}
+
+func TestMeasureLANNoServer(t *testing.T) {
+	// find a port that nothing is listening on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	_, _, err = measureLAN(context.Background(), addr, "download", 2, 500*time.Millisecond, time.Second)
+	if err == nil {
+		t.Error("expected an error without a server")
+	}
+}
+
+func TestRunLANTest(t *testing.T) {
+	addr := startLANServer(t)
+
+	var row SpeedResult
+	runLANTest(context.Background(), addr, 2, 300*time.Millisecond, 5*time.Second, &row)
+	if row.Error != "" {
+		t.Fatal(row.Error)
+	}
+	if row.Test != "lan" || row.Server != addr || row.Streams != 2 {
+		t.Errorf("expected a lan test against %s with 2 streams, got %v", addr, &row)
+	}
+	if row.Down <= 0 || row.Up <= 0 {
+		t.Errorf("expected positive download and upload speeds, got %v", &row)
+	}
+}
+
+func TestParseLANAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"192.168.88.10", "192.168.88.10:5201"},
+		{"192.168.88.10:9000", "192.168.88.10:9000"},
+		{"nas.local", "nas.local:5201"},
+		{"fe80::1", "[fe80::1]:5201"},
+		{"[fe80::1]:9000", "[fe80::1]:9000"},
+	}
+	for _, test := range tests {
+		if got := parseLANAddr(test.addr); got != test.want {
+			t.Errorf("parseLANAddr(%q): expected %q, got %q", test.addr, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// pingCount is the number of pings used to measure latency and jitter
+const pingCount = 10
+
+// pingServer measures the latency to a server in the same way as
+// Server.PingTestContext, by halving the shortest of several round trips, and
+// also measures the jitter, which speedtest-go does not report. Jitter is the
+// mean difference between consecutive latencies.
+func pingServer(ctx context.Context, s *speedtest.Server) (latency, jitter time.Duration, err error) {
+	pingURL := strings.Split(s.URL, "/upload.php")[0] + "/latency.txt"
+
+	var samples []time.Duration
+	for i := 0; i < pingCount; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, pingURL, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		begin := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, 0, err
+		}
+		samples = append(samples, time.Since(begin)/2)
+		resp.Body.Close()
+	}
+	latency, jitter = summarizePings(samples)
+	return latency, jitter, nil
+}
+
+// summarizePings gets the shortest latency and the jitter from two or more
+// latencies, in the order that they were measured
+func summarizePings(samples []time.Duration) (latency, jitter time.Duration) {
+	latency = samples[0]
+	var total time.Duration
+	for i, sample := range samples {
+		if sample < latency {
+			latency = sample
+		}
+		if i > 0 {
+			total += time.Duration(math.Abs(float64(sample - samples[i-1])))
+		}
+	}
+	jitter = total / time.Duration(len(samples)-1)
+	return latency, jitter
+}
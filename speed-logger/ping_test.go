@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizePings(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		samples []time.Duration
+		latency time.Duration
+		jitter  time.Duration
+	}{
+		{[]time.Duration{ms, ms}, ms, 0},
+		{[]time.Duration{5 * ms, 3 * ms}, 3 * ms, 2 * ms},
+		{[]time.Duration{10 * ms, 20 * ms, 15 * ms, 30 * ms}, 10 * ms, 10 * ms},
+		{[]time.Duration{4 * ms, 4 * ms, 4 * ms, 1 * ms}, ms, ms},
+		{[]time.Duration{1, 2, 4}, 1, 1}, // jitter rounds down
+	}
+	for _, test := range tests {
+		latency, jitter := summarizePings(test.samples)
+		if latency != test.latency || jitter != test.jitter {
+			t.Errorf("summarizePings(%v): expected %v and %v, got %v and %v",
+				test.samples, test.latency, test.jitter, latency, jitter)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// serverPicker chooses which speedtest.net servers to test on each run
+type serverPicker struct {
+	mode    string // "closest" or "rotate"
+	closest int    // number of closest servers to choose from
+	pinned  []int  // server IDs to choose from instead of the closest servers
+	next    int    // index of the next candidate when rotating
+}
+
+// candidates gets the servers to choose from, in order of distance
+func (p *serverPicker) candidates(list speedtest.ServerList) (speedtest.Servers, error) {
+	if len(p.pinned) == 0 {
+		n := p.closest
+		if n > len(list.Servers) {
+			n = len(list.Servers)
+		}
+		return list.Servers[:n], nil
+	}
+
+	// unlike ServerList.FindServer, do not fall back to the closest server,
+	// and take each server once even if its ID is pinned more than once
+	var servers speedtest.Servers
+	for _, s := range list.Servers {
+		id, _ := strconv.Atoi(s.ID)
+		for _, pinned := range p.pinned {
+			if id == pinned {
+				servers = append(servers, s)
+				break
+			}
+		}
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("none of the servers %v are in the server list", p.pinned)
+	}
+	return servers, nil
+}
+
+// pick chooses up to count servers from the server list. In "closest" mode
+// these are always the closest candidates, and in "rotate" mode they move on
+// by one candidate on each run, so that an overloaded server is not used
+// every time.
+func (p *serverPicker) pick(list speedtest.ServerList, count int) (speedtest.Servers, error) {
+	candidates, err := p.candidates(list)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("speedtest found zero servers")
+	}
+	if count > len(candidates) {
+		count = len(candidates)
+	}
+
+	if p.mode != "rotate" {
+		return candidates[:count], nil
+	}
+
+	var servers speedtest.Servers
+	for i := 0; i < count; i++ {
+		servers = append(servers, candidates[(p.next+i)%len(candidates)])
+	}
+	p.next = (p.next + 1) % len(candidates)
+	return servers, nil
+}
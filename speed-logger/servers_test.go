@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// fakeServerList is a server list with the given IDs, in order of distance
+func fakeServerList(ids ...string) speedtest.ServerList {
+	var list speedtest.ServerList
+	for i, id := range ids {
+		list.Servers = append(list.Servers, &speedtest.Server{ID: id, Distance: float64(i)})
+	}
+	return list
+}
+
+func serverIDs(servers speedtest.Servers) string {
+	var ids []string
+	for _, s := range servers {
+		ids = append(ids, s.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestServerPicker(t *testing.T) {
+	list := fakeServerList("10", "20", "30", "40", "50")
+	tests := []struct {
+		name   string
+		picker serverPicker
+		count  int
+		want   []string // IDs picked on each successive run
+	}{
+		{"closest", serverPicker{mode: "closest", closest: 3}, 1, []string{"10", "10", "10"}},
+		{"closest pair", serverPicker{mode: "closest", closest: 3}, 2, []string{"10,20", "10,20"}},
+		{"rotate", serverPicker{mode: "rotate", closest: 3}, 1, []string{"10", "20", "30", "10"}},
+		{"rotate pair", serverPicker{mode: "rotate", closest: 3}, 2, []string{"10,20", "20,30", "30,10", "10,20"}},
+		{"count above candidates", serverPicker{mode: "closest", closest: 2}, 5, []string{"10,20", "10,20"}},
+		{"rotate count above candidates", serverPicker{mode: "rotate", closest: 2}, 5, []string{"10,20", "20,10"}},
+		{"closest above list", serverPicker{mode: "closest", closest: 10}, 10, []string{"10,20,30,40,50"}},
+		{"pinned", serverPicker{mode: "closest", closest: 1, pinned: []int{40, 20}}, 1, []string{"20", "20"}},
+		{"pinned rotate", serverPicker{mode: "rotate", closest: 1, pinned: []int{40, 20}}, 1, []string{"20", "40", "20"}},
+		{"pinned twice", serverPicker{mode: "rotate", pinned: []int{30, 30, 50}}, 5, []string{"30,50", "50,30"}},
+		{"pinned not listed", serverPicker{mode: "closest", pinned: []int{30, 99}}, 2, []string{"30"}},
+	}
+	for _, test := range tests {
+		for run, want := range test.want {
+			got, err := test.picker.pick(list, test.count)
+			if err != nil {
+				t.Errorf("%s run %d: %v", test.name, run, err)
+				break
+			}
+			if ids := serverIDs(got); ids != want {
+				t.Errorf("%s run %d: expected servers %s, got %s", test.name, run, want, ids)
+			}
+		}
+	}
+}
+
+func TestServerPickerErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		list   speedtest.ServerList
+		picker serverPicker
+	}{
+		{"no pinned servers listed", fakeServerList("10", "20"), serverPicker{mode: "closest", pinned: []int{99}}},
+		{"empty list", fakeServerList(), serverPicker{mode: "closest", closest: 3}},
+	}
+	for _, test := range tests {
+		got, err := test.picker.pick(test.list, 1)
+		if err == nil {
+			t.Errorf("%s: expected an error, got servers %s", test.name, serverIDs(got))
+		}
+	}
+}
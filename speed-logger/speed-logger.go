@@ -1,30 +1,129 @@
+//go:generate protoc -I/usr/local/include -I. --go_out=. speed.proto
+
 package main
 
 import (
 	"context"
 	_ "embed"
+	"fmt"
 	"log"
+	"math"
+	"net"
+	"os"
+	"strings"
 	"time"
 
+	storage "cloud.google.com/go/bigquery/storage/apiv1beta2"
+
 	"cloud.google.com/go/logging"
 	"github.com/alexflint/go-arg"
+	"github.com/monasticacademy/maple-network-tools/sink"
 	"github.com/showwin/speedtest-go/speedtest"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
+	"google.golang.org/protobuf/proto"
 )
 
 //go:embed secrets/service-account.json
 var googleCredentials []byte
 
-// logEntry is the data that send to cloud logging once per N seconds
-type logEntry struct {
-	Machine  string  // machine from which this test was peformed (e.g. "chromebook-1")
-	Location string  // physical location from which this test was performed (e.g. "garuda")
-	Network  string  // the wifi or ethernet network from which this test was performed (e.g. "maple-wifi")
-	Carrier  string  // the carrier over which this test was performed (e.g. "vtel")
-	Latency  int64   // ping time in milliseconds
-	Down     float64 // download speed in megabits per second
-	Up       float64 // upload speed in megabits per second
+const streamingTraceID = "speed-logger" // identified this client in bigquery debug logs
+
+// runTest runs the ping, download and upload tests against one server, and
+// fills in the server and the results in row. Failed tests are logged, left
+// as zero, and recorded in the Error field.
+func runTest(ctx context.Context, s *speedtest.Server, timeout time.Duration, row *SpeedResult) {
+	row.Server = fmt.Sprintf("%s (%s)", s.Name, s.Sponsor)
+	row.ServerID = s.ID
+	row.Distance = math.Round(s.Distance*10) / 10
+
+	log.Printf("running a speed test against %s [%s], %.0fkm away...", row.Server, s.ID, s.Distance)
+
+	var errs []string
+	fail := func(test string, err error) {
+		log.Printf("%s test failed: %v", test, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", test, err))
+	}
+
+	// do the ping test
+	pingCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	latency, jitter, err := pingServer(pingCtx, s)
+	if err == nil {
+		row.Latency = latency.Milliseconds()
+		row.Jitter = float64(jitter.Microseconds()) / 1000
+	} else {
+		fail("ping", err)
+	}
+
+	// do the download test
+	downloadCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = s.DownloadTestContext(downloadCtx, false)
+	if err == nil {
+		row.Down = s.DLSpeed
+	} else {
+		fail("download", err)
+	}
+
+	// do the upload test
+	uploadCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = s.UploadTestContext(uploadCtx, false)
+	if err == nil {
+		row.Up = s.ULSpeed
+	} else {
+		fail("upload", err)
+	}
+
+	row.Error = strings.Join(errs, "; ")
+}
+
+// testInternet picks count speedtest.net servers, tests each of them, and
+// returns the fastest result. Failures to fetch the server list are recorded
+// in the Error field, so that outages show up in the table.
+func testInternet(ctx context.Context, picker *serverPicker, count int, timeout time.Duration, row *SpeedResult) *SpeedResult {
+	row.Test = "internet"
+
+	// fetch the server list on each run, since servers come and go
+	var servers speedtest.Servers
+	serverList, err := fetchServers(ctx)
+	if err == nil {
+		servers, err = picker.pick(serverList, count)
+	}
+	if err != nil {
+		log.Println(err)
+		row.Error = err.Error()
+		return row
+	}
+
+	// keep the result with the fastest download, or the fastest upload
+	// if the downloads are equally fast
+	base := row
+	for i, s := range servers {
+		result := proto.Clone(base).(*SpeedResult)
+		runTest(ctx, s, timeout, result)
+		log.Printf("%v\n", result)
+
+		if i == 0 || result.Down > row.Down || (result.Down == row.Down && result.Up > row.Up) {
+			row = result
+		}
+	}
+	return row
+}
+
+// fetchServers gets the speedtest.net servers, sorted by distance
+func fetchServers(ctx context.Context) (speedtest.ServerList, error) {
+	user, err := speedtest.FetchUserInfoContext(ctx)
+	if err != nil {
+		return speedtest.ServerList{}, fmt.Errorf("error fetching user info: %w", err)
+	}
+
+	serverList, err := speedtest.FetchServerListContext(ctx, user)
+	if err != nil {
+		return speedtest.ServerList{}, fmt.Errorf("error fetching server list: %w", err)
+	}
+	return serverList, nil
 }
 
 func main() {
@@ -32,58 +131,129 @@ func main() {
 
 	// process command line arguments
 	var args struct {
-		Machine  string `arg:"required,env:SPEEDLOGGER_MACHINE"`
-		Location string `arg:"required,env:SPEEDLOGGER_LOCATION"`
-		Network  string `arg:"required,env:SPEEDLOGGER_NETWORK"`
-		Carrier  string `arg:"required,env:SPEEDLOGGER_CARRIER"`
-		LogName  string
-		Interval time.Duration
-		Timeout  time.Duration `help:"timeout for speed test"`
+		Machine      string `arg:"env:SPEEDLOGGER_MACHINE"`
+		Location     string `arg:"env:SPEEDLOGGER_LOCATION"`
+		Network      string `arg:"env:SPEEDLOGGER_NETWORK"`
+		Carrier      string `arg:"env:SPEEDLOGGER_CARRIER"`
+		Dataset      string `help:"Bigquery dataset name"`
+		Table        string `help:"Bigquery table name"`
+		CloudLogging bool   `help:"also write each result to cloud logging under --logname"`
+		LogName      string
+		Print        bool `help:"print results to stdout instead of sending them to google cloud"`
+		Interval     time.Duration
+		Timeout      time.Duration `help:"timeout for speed test"`
+		Pick         string        `help:"how to pick a server on each run: closest or rotate"`
+		Closest      int           `help:"number of closest servers to pick from"`
+		ServerID     []int         `help:"speedtest.net server IDs to pick from instead of the closest servers"`
+		Best         bool          `help:"test two servers on each run and keep the faster result"`
+		Serve        string        `help:"serve lan speed tests on this address, such as :5201, instead of running tests"`
+		LAN          string        `arg:"env:SPEEDLOGGER_LAN" help:"address of a speed-logger --serve to run a lan test against on each run"`
+		LANOnly      bool          `help:"run only the lan test, not the speedtest.net test"`
+		Streams      int           `help:"number of parallel connections for the lan test"`
+		LANDuration  time.Duration `help:"duration of each of the lan download and upload tests"`
+		Once         bool          `help:"run the tests once and exit"`
 	}
+	args.Dataset = "maple"
+	args.Table = "internet_speed"
 	args.LogName = "internet-speed"
 	args.Interval = 30 * time.Minute
 	args.Timeout = 5 * time.Minute
-	arg.MustParse(&args)
+	args.Pick = "closest"
+	args.Closest = 5
+	args.Streams = 4
+	args.LANDuration = 10 * time.Second
+	p := arg.MustParse(&args)
 
-	// unpack google credentials
-	creds, err := google.CredentialsFromJSON(ctx, googleCredentials)
-	if err != nil {
-		log.Fatal("error parsing credentials: ", err)
+	// run as a lan test server instead of running tests
+	if args.Serve != "" {
+		listener, err := net.Listen("tcp", args.Serve)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Fatal(serveLAN(listener))
+	}
+
+	if args.Machine == "" || args.Location == "" || args.Network == "" || args.Carrier == "" {
+		p.Fail("--machine, --location, --network and --carrier are required")
+	}
+	if args.LANOnly && args.LAN == "" {
+		p.Fail("--lanonly requires --lan")
+	}
+	if args.Streams < 1 {
+		p.Fail("--streams must be at least 1")
+	}
+
+	if args.Pick != "closest" && args.Pick != "rotate" {
+		p.Fail("--pick must be closest or rotate")
+	}
+	if args.Closest < 1 {
+		p.Fail("--closest must be at least 1")
 	}
 
 	log.Println("machine:", args.Machine)
 	log.Println("location:", args.Location)
 	log.Println("netwowrk:", args.Network)
 	log.Println("carrier:", args.Carrier)
-	log.Println("project:", creds.ProjectID)
 	log.Println("log interval:", args.Interval)
-
-	// set up the logger
-	logClient, err := logging.NewClient(ctx, creds.ProjectID,
-		option.WithCredentialsJSON(googleCredentials))
-	if err != nil {
-		log.Fatal("error creating logging client: ", err)
+	if args.LAN != "" {
+		args.LAN = parseLANAddr(args.LAN)
+		log.Printf("lan test: %s with %d streams for %v", args.LAN, args.Streams, args.LANDuration)
 	}
-	defer logClient.Close()
 
-	lg := logClient.Logger(args.LogName)
+	// lg is nil unless results are also written to cloud logging
+	var out sink.Sink
+	var lg *logging.Logger
+	if args.Print {
+		out = &sink.Print{W: os.Stdout}
+	} else {
+		// unpack google credentials
+		creds, err := google.CredentialsFromJSON(ctx, googleCredentials)
+		if err != nil {
+			log.Fatal("error parsing credentials: ", err)
+		}
 
-	// set up the speed test
-	user, err := speedtest.FetchUserInfo()
-	if err != nil {
-		log.Fatal(err)
-	}
+		log.Println("project:", creds.ProjectID)
+		log.Println("dataset:", args.Dataset)
+		log.Println("table:", args.Table)
 
-	serverList, err := speedtest.FetchServerList(user)
-	if err != nil {
-		log.Fatal(err)
+		// create the bigquery client for stream insertion
+		bqClient, err := storage.NewBigQueryWriteClient(ctx,
+			option.WithCredentialsJSON(googleCredentials))
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer bqClient.Close()
+
+		out, err = sink.NewBigQuery(ctx, bqClient, creds.ProjectID, args.Dataset, args.Table, &SpeedResult{}, streamingTraceID)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// set up the logger
+		if args.CloudLogging {
+			log.Println("log name:", args.LogName)
+
+			logClient, err := logging.NewClient(ctx, creds.ProjectID,
+				option.WithCredentialsJSON(googleCredentials))
+			if err != nil {
+				log.Fatal("error creating logging client: ", err)
+			}
+			defer logClient.Close()
+
+			lg = logClient.Logger(args.LogName)
+		}
 	}
-	if len(serverList.Servers) == 0 {
-		log.Fatal("speedtest found zero servers")
+
+	picker := serverPicker{
+		mode:    args.Pick,
+		closest: args.Closest,
+		pinned:  args.ServerID,
 	}
 
-	// the servers are returned to us sorted by distance, so use the first one
-	s := serverList.Servers[0]
+	count := 1
+	if args.Best {
+		count = 2
+	}
 
 	// create the ticker to run speed tests
 	ticker := time.NewTicker(args.Interval)
@@ -91,45 +261,49 @@ func main() {
 
 	// the following loop ticks immediately, then waits for the intervals
 	for ; true; <-ticker.C {
-		var entry logEntry
-		entry.Machine = args.Machine
-		entry.Location = args.Location
-		entry.Network = args.Network
-		entry.Carrier = args.Carrier
-
-		log.Println("running a speed test...")
-
-		// do the ping test
-		pingCtx, _ := context.WithTimeout(ctx, args.Timeout)
-		err = s.PingTestContext(pingCtx)
-		if err == nil {
-			entry.Latency = s.Latency.Milliseconds()
-		} else {
-			log.Println("ping test failed: ", err)
-			lg.StandardLogger(logging.Error).Println("ping test failed: ", err)
+		begin := time.Now()
+		newRow := func() *SpeedResult {
+			return &SpeedResult{
+				Timestamp: begin.UnixMicro(),
+				Machine:   args.Machine,
+				Location:  args.Location,
+				Network:   args.Network,
+				Carrier:   args.Carrier,
+			}
 		}
 
-		// do the download test
-		downloadCtx, _ := context.WithTimeout(ctx, args.Timeout)
-		err = s.DownloadTestContext(downloadCtx, false)
-		if err == nil {
-			entry.Down = s.DLSpeed
-		} else {
-			log.Println("download test failed: ", err)
-			lg.StandardLogger(logging.Error).Println("download test failed: ", err)
+		var rows []*SpeedResult
+		if !args.LANOnly {
+			rows = append(rows, testInternet(ctx, &picker, count, args.Timeout, newRow()))
+		}
+		if args.LAN != "" {
+			row := newRow()
+			runLANTest(ctx, args.LAN, args.Streams, args.LANDuration, args.Timeout, row)
+			log.Printf("%v\n", row)
+			rows = append(rows, row)
 		}
 
-		// do the upload test
-		uploadCtx, _ := context.WithTimeout(ctx, args.Timeout)
-		err = s.UploadTestContext(uploadCtx, false)
-		if err == nil {
-			entry.Up = s.ULSpeed
-		} else {
-			log.Println("upload test failed: ", err)
-			lg.StandardLogger(logging.Error).Println("upload test failed: ", err)
+		var msgs []proto.Message
+		for _, row := range rows {
+			msgs = append(msgs, row)
+		}
+		err := out.Write(ctx, msgs)
+		if err != nil {
+			log.Println("error writing speed results: ", err)
 		}
 
-		log.Printf("%#v\n", entry)
-		lg.Log(logging.Entry{Payload: entry})
+		if lg != nil {
+			for _, row := range rows {
+				severity := logging.Default
+				if row.Error != "" {
+					severity = logging.Error
+				}
+				lg.Log(logging.Entry{Payload: row, Severity: severity})
+			}
+		}
+
+		if args.Once {
+			return
+		}
 	}
 }
@@ -0,0 +1,286 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        v3.19.1
+// source: speed.proto
+
+package main
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SpeedResult struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp     int64   `protobuf:"varint,10,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`          // microseconds since epoch
+	Machine       string  `protobuf:"bytes,20,opt,name=Machine,proto3" json:"Machine,omitempty"`               // machine from which this test was peformed (e.g. "chromebook-1")
+	Location      string  `protobuf:"bytes,30,opt,name=Location,proto3" json:"Location,omitempty"`             // physical location from which this test was performed (e.g. "garuda")
+	Network       string  `protobuf:"bytes,40,opt,name=Network,proto3" json:"Network,omitempty"`               // the wifi or ethernet network from which this test was performed (e.g. "maple-wifi")
+	Carrier       string  `protobuf:"bytes,50,opt,name=Carrier,proto3" json:"Carrier,omitempty"`               // the carrier over which this test was performed (e.g. "vtel")
+	Server        string  `protobuf:"bytes,60,opt,name=Server,proto3" json:"Server,omitempty"`                 // name and sponsor of the speedtest.net server, or address of the lan server
+	ServerID      string  `protobuf:"bytes,70,opt,name=ServerID,proto3" json:"ServerID,omitempty"`             // speedtest.net server ID
+	Distance      float64 `protobuf:"fixed64,80,opt,name=Distance,proto3" json:"Distance,omitempty"`           // distance to the server in kilometers
+	Latency       int64   `protobuf:"varint,90,opt,name=Latency,proto3" json:"Latency,omitempty"`              // ping time in milliseconds
+	Jitter        float64 `protobuf:"fixed64,100,opt,name=Jitter,proto3" json:"Jitter,omitempty"`              // mean difference between consecutive ping times in milliseconds
+	Down          float64 `protobuf:"fixed64,110,opt,name=Down,proto3" json:"Down,omitempty"`                  // download speed in megabits per second
+	Up            float64 `protobuf:"fixed64,120,opt,name=Up,proto3" json:"Up,omitempty"`                      // upload speed in megabits per second
+	Error         string  `protobuf:"bytes,130,opt,name=Error,proto3" json:"Error,omitempty"`                  // errors from any tests that failed, or empty
+	Test          string  `protobuf:"bytes,140,opt,name=Test,proto3" json:"Test,omitempty"`                    // "internet" for speedtest.net or "lan" for a speed-logger server
+	LoadedLatency int64   `protobuf:"varint,150,opt,name=LoadedLatency,proto3" json:"LoadedLatency,omitempty"` // ping time in milliseconds during the lan download and upload
+	Streams       int64   `protobuf:"varint,160,opt,name=Streams,proto3" json:"Streams,omitempty"`             // number of parallel connections in the lan test
+}
+
+func (x *SpeedResult) Reset() {
+	*x = SpeedResult{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_speed_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpeedResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpeedResult) ProtoMessage() {}
+
+func (x *SpeedResult) ProtoReflect() protoreflect.Message {
+	mi := &file_speed_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpeedResult.ProtoReflect.Descriptor instead.
+func (*SpeedResult) Descriptor() ([]byte, []int) {
+	return file_speed_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SpeedResult) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetMachine() string {
+	if x != nil {
+		return x.Machine
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetCarrier() string {
+	if x != nil {
+		return x.Carrier
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetServerID() string {
+	if x != nil {
+		return x.ServerID
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetDistance() float64 {
+	if x != nil {
+		return x.Distance
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetLatency() int64 {
+	if x != nil {
+		return x.Latency
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetJitter() float64 {
+	if x != nil {
+		return x.Jitter
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetDown() float64 {
+	if x != nil {
+		return x.Down
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetUp() float64 {
+	if x != nil {
+		return x.Up
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetTest() string {
+	if x != nil {
+		return x.Test
+	}
+	return ""
+}
+
+func (x *SpeedResult) GetLoadedLatency() int64 {
+	if x != nil {
+		return x.LoadedLatency
+	}
+	return 0
+}
+
+func (x *SpeedResult) GetStreams() int64 {
+	if x != nil {
+		return x.Streams
+	}
+	return 0
+}
+
+var File_speed_proto protoreflect.FileDescriptor
+
+var file_speed_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x73, 0x70, 0x65, 0x65, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x74,
+	0x75, 0x74, 0x6f, 0x72, 0x69, 0x61, 0x6c, 0x22, 0xa9, 0x03, 0x0a, 0x0b, 0x53, 0x70, 0x65, 0x65,
+	0x64, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x4d, 0x61, 0x63, 0x68, 0x69, 0x6e, 0x65,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4d, 0x61, 0x63, 0x68, 0x69, 0x6e, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x1e, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x4c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x4e,
+	0x65, 0x74, 0x77, 0x6f, 0x72, 0x6b, 0x18, 0x28, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x4e, 0x65,
+	0x74, 0x77, 0x6f, 0x72, 0x6b, 0x12, 0x18, 0x0a, 0x07, 0x43, 0x61, 0x72, 0x72, 0x69, 0x65, 0x72,
+	0x18, 0x32, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x43, 0x61, 0x72, 0x72, 0x69, 0x65, 0x72, 0x12,
+	0x16, 0x0a, 0x06, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x3c, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x53, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x49, 0x44, 0x18, 0x46, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x53, 0x65, 0x72, 0x76, 0x65,
+	0x72, 0x49, 0x44, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x18,
+	0x50, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x12,
+	0x18, 0x0a, 0x07, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x5a, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x07, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x4a, 0x69, 0x74,
+	0x74, 0x65, 0x72, 0x18, 0x64, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x4a, 0x69, 0x74, 0x74, 0x65,
+	0x72, 0x12, 0x12, 0x0a, 0x04, 0x44, 0x6f, 0x77, 0x6e, 0x18, 0x6e, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x04, 0x44, 0x6f, 0x77, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x55, 0x70, 0x18, 0x78, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x02, 0x55, 0x70, 0x12, 0x15, 0x0a, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x82,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x12, 0x13, 0x0a, 0x04,
+	0x54, 0x65, 0x73, 0x74, 0x18, 0x8c, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x54, 0x65, 0x73,
+	0x74, 0x12, 0x25, 0x0a, 0x0d, 0x4c, 0x6f, 0x61, 0x64, 0x65, 0x64, 0x4c, 0x61, 0x74, 0x65, 0x6e,
+	0x63, 0x79, 0x18, 0x96, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x4c, 0x6f, 0x61, 0x64, 0x65,
+	0x64, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x19, 0x0a, 0x07, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x73, 0x18, 0xa0, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x53, 0x74, 0x72, 0x65,
+	0x61, 0x6d, 0x73, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x6d, 0x61, 0x69, 0x6e, 0x62, 0x06, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_speed_proto_rawDescOnce sync.Once
+	file_speed_proto_rawDescData = file_speed_proto_rawDesc
+)
+
+func file_speed_proto_rawDescGZIP() []byte {
+	file_speed_proto_rawDescOnce.Do(func() {
+		file_speed_proto_rawDescData = protoimpl.X.CompressGZIP(file_speed_proto_rawDescData)
+	})
+	return file_speed_proto_rawDescData
+}
+
+var file_speed_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_speed_proto_goTypes = []interface{}{
+	(*SpeedResult)(nil), // 0: tutorial.SpeedResult
+}
+var file_speed_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_speed_proto_init() }
+func file_speed_proto_init() {
+	if File_speed_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_speed_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SpeedResult); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_speed_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_speed_proto_goTypes,
+		DependencyIndexes: file_speed_proto_depIdxs,
+		MessageInfos:      file_speed_proto_msgTypes,
+	}.Build()
+	File_speed_proto = out.File
+	file_speed_proto_rawDesc = nil
+	file_speed_proto_goTypes = nil
+	file_speed_proto_depIdxs = nil
+}
@@ -0,0 +1,342 @@
+// Package telnetsession drives the command line of a device over telnet. It
+// waits for prompts rather than for fixed delays, so each command returns as
+// soon as the device has printed its output.
+package telnetsession
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginPrompts and PasswordPrompts are what devices print when asking for
+// credentials, and ShellPrompts are the last character of a command prompt
+var (
+	LoginPrompts    = []string{"login:", "Login:", "Username:"}
+	PasswordPrompts = []string{"password:", "Password:"}
+	ShellPrompts    = []string{">", "#", "$"}
+)
+
+var (
+	// ErrLoginFailed is returned when the device asks for credentials again
+	ErrLoginFailed = errors.New("login failed, check the username and password")
+
+	// ErrTimeout is returned when the device does not print a prompt in time
+	ErrTimeout = errors.New("timed out waiting for a prompt")
+)
+
+// settleTime is how long the device must stop sending before a prompt at the
+// end of the output counts, so that a ">" in the middle of a banner is not
+// taken for a prompt
+const settleTime = 50 * time.Millisecond
+
+// telnet commands, from RFC 854
+const (
+	cmdSE   = 240 // end of subnegotiation
+	cmdSB   = 250 // start of subnegotiation
+	cmdWILL = 251
+	cmdWONT = 252
+	cmdDO   = 253
+	cmdDONT = 254
+	cmdIAC  = 255 // interpret as command, or a 255 data byte when doubled
+)
+
+// Session is a telnet connection to the command line of a device. Every
+// option the device offers or asks for is refused, so the connection stays
+// in the default mode of RFC 854, and telnet commands are removed from the
+// data before it is matched against prompts.
+type Session struct {
+	conn    net.Conn
+	timeout time.Duration // how long to wait for each prompt
+	prompt  string        // the command prompt, learned at login
+
+	data      chan byte     // bytes read from the connection
+	readErr   error         // why reading stopped, valid once data is closed
+	done      chan struct{} // closed by Close to stop the reader
+	next      []byte        // a byte read while waiting for output to settle
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Dial connects to a telnet server. The timeout applies to connecting and to
+// waiting for each prompt.
+func Dial(addr string, timeout time.Duration) (*Session, error) {
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return newSession(conn, timeout), nil
+}
+
+func newSession(conn net.Conn, timeout time.Duration) *Session {
+	s := Session{
+		conn:    conn,
+		timeout: timeout,
+		data:    make(chan byte, 4096),
+		done:    make(chan struct{}),
+	}
+	go s.read()
+	return &s
+}
+
+// read copies data bytes from the connection to the data channel until the
+// connection fails or the session is closed, answering option negotiation
+// and dropping subnegotiation along the way
+func (s *Session) read() {
+	defer close(s.data)
+	r := bufio.NewReader(s.conn)
+	for {
+		b, err := s.readData(r)
+		if err != nil {
+			s.readErr = err
+			return
+		}
+		select {
+		case s.data <- b:
+		case <-s.done:
+			s.readErr = io.ErrClosedPipe
+			return
+		}
+	}
+}
+
+// readData gets the next data byte from the connection, handling any telnet
+// commands in front of it
+func (s *Session) readData(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil || b != cmdIAC {
+			return b, err
+		}
+
+		cmd, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch cmd {
+		case cmdIAC:
+			return cmdIAC, nil
+		case cmdWILL, cmdDO:
+			opt, err := r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			// refuse the option; WONT and DONT need no answer since every
+			// option starts out disabled
+			reply := byte(cmdDONT)
+			if cmd == cmdDO {
+				reply = cmdWONT
+			}
+			_, err = s.conn.Write([]byte{cmdIAC, reply, opt})
+			if err != nil {
+				return 0, err
+			}
+		case cmdWONT, cmdDONT:
+			_, err := r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+		case cmdSB:
+			err := skipSubnegotiation(r)
+			if err != nil {
+				return 0, err
+			}
+		}
+		// any other command, such as NOP or go ahead, carries no data
+	}
+}
+
+// skipSubnegotiation reads up to and including the IAC SE that ends a
+// subnegotiation
+func skipSubnegotiation(r *bufio.Reader) error {
+	var iac bool
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if iac && b == cmdSE {
+			return nil
+		}
+		// a doubled IAC is a 255 byte in the parameters
+		iac = !iac && b == cmdIAC
+	}
+}
+
+// Close closes the telnet connection. Closing a session more than once is
+// not an error.
+func (s *Session) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.closeErr = s.conn.Close()
+	})
+	return s.closeErr
+}
+
+// Prompt gets the command prompt that was learned at login
+func (s *Session) Prompt() string {
+	return s.prompt
+}
+
+// Login answers the login and password prompts, which are only answered if the
+// device asks, and then records the command prompt so that Run can tell where
+// the output of each command ends
+func (s *Session) Login(user, pass string) error {
+	var sentUser, sentPass bool
+	for {
+		out, match, err := s.Expect(LoginPrompts, PasswordPrompts, ShellPrompts)
+		if err != nil {
+			return fmt.Errorf("error waiting for login prompt: %w", err)
+		}
+
+		switch match {
+		case 0:
+			if sentUser {
+				return ErrLoginFailed
+			}
+			err = s.WriteLine(user)
+			sentUser = true
+		case 1:
+			if sentPass {
+				return ErrLoginFailed
+			}
+			err = s.WriteLine(pass)
+			sentPass = true
+		case 2:
+			// the prompt is the last line of output, such as "RW-CLI>"
+			lines := strings.Split(strings.ReplaceAll(out, "\r", "\n"), "\n")
+			s.prompt = strings.TrimSpace(lines[len(lines)-1])
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// SetPrompt runs a command that changes the command prompt, such as setting
+// PS1, and waits for the new prompt
+func (s *Session) SetPrompt(cmd, prompt string) error {
+	err := s.WriteLine(cmd)
+	if err != nil {
+		return err
+	}
+	_, _, err = s.Expect([]string{prompt})
+	if err != nil {
+		return fmt.Errorf("error setting prompt: %w", err)
+	}
+	s.prompt = strings.TrimSpace(prompt)
+	return nil
+}
+
+// Run sends a command and returns its output, without the echo of the command
+// or the prompt that follows it
+func (s *Session) Run(cmd string) (string, error) {
+	if s.prompt == "" {
+		return "", errors.New("run called before login")
+	}
+
+	err := s.WriteLine(cmd)
+	if err != nil {
+		return "", err
+	}
+
+	out, _, err := s.Expect([]string{s.prompt})
+	if err != nil {
+		return "", fmt.Errorf("error running %q: %w", cmd, err)
+	}
+
+	out = strings.TrimRight(out, " ")
+	out = strings.TrimSuffix(out, s.prompt)
+	out = strings.TrimRight(out, "\r\n")
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	if line, rest, ok := strings.Cut(out, "\n"); ok && strings.TrimSpace(line) == cmd {
+		out = rest
+	} else if strings.TrimSpace(out) == cmd {
+		out = ""
+	}
+	return out, nil
+}
+
+// WriteLine sends a line to the device, escaping any 255 bytes so that they
+// are not taken for telnet commands
+func (s *Session) WriteLine(line string) error {
+	escaped := bytes.ReplaceAll([]byte(line), []byte{cmdIAC}, []byte{cmdIAC, cmdIAC})
+	_, err := s.conn.Write(append(escaped, '\r', '\n'))
+	return err
+}
+
+// Expect reads until the output ends with one of the given prompts, ignoring
+// trailing spaces, and returns the output and the index of the group of
+// prompts that matched. It fails with ErrTimeout if no prompt arrives within
+// the session timeout.
+func (s *Session) Expect(groups ...[]string) (string, int, error) {
+	deadline := time.NewTimer(s.timeout)
+	defer deadline.Stop()
+
+	var buf bytes.Buffer
+	for {
+		b, err := s.readByte(deadline.C)
+		if err != nil {
+			return buf.String(), -1, err
+		}
+		buf.WriteByte(b)
+
+		match := matchPrompt(buf.Bytes(), groups)
+		if match < 0 {
+			continue
+		}
+
+		// wait for the device to stop sending before taking this for a prompt
+		settle := time.NewTimer(settleTime)
+		b, err = s.readByte(settle.C)
+		settle.Stop()
+		if errors.Is(err, ErrTimeout) {
+			return buf.String(), match, nil
+		}
+		if err != nil {
+			return buf.String(), -1, err
+		}
+		s.next = append(s.next, b)
+	}
+}
+
+// readByte gets the next byte from the device, or fails with ErrTimeout once
+// the timer fires
+func (s *Session) readByte(timeout <-chan time.Time) (byte, error) {
+	if len(s.next) > 0 {
+		b := s.next[0]
+		s.next = s.next[1:]
+		return b, nil
+	}
+
+	select {
+	case b, ok := <-s.data:
+		if !ok {
+			return 0, s.readErr
+		}
+		return b, nil
+	case <-timeout:
+		return 0, fmt.Errorf("%w after %v", ErrTimeout, s.timeout)
+	}
+}
+
+// matchPrompt gets the index of the first group with a prompt at the end of
+// the output, ignoring trailing spaces, or -1 if there is none
+func matchPrompt(out []byte, groups [][]string) int {
+	tail := bytes.TrimRight(out, " ")
+	for i, prompts := range groups {
+		for _, prompt := range prompts {
+			if bytes.HasSuffix(tail, []byte(strings.TrimRight(prompt, " "))) {
+				return i
+			}
+		}
+	}
+	return -1
+}
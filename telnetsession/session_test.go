@@ -0,0 +1,276 @@
+package telnetsession
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// telnet commands and options sent by the fake servers below
+const (
+	iac  = "\xff"
+	will = "\xfb"
+	wont = "\xfc"
+	do   = "\xfd"
+	dont = "\xfe"
+	sb   = "\xfa"
+	se   = "\xf0"
+
+	optEcho            = "\x01"
+	optSuppressGoAhead = "\x03"
+	optTerminalType    = "\x18"
+)
+
+// startServer listens on a random localhost port and runs serve on the first
+// connection. The raw connection is used so that the server can send telnet
+// commands as well as data.
+func startServer(t *testing.T, serve func(conn net.Conn, r *bufio.Reader)) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn, bufio.NewReader(conn))
+	}()
+	return listener.Addr().String()
+}
+
+// readLine reads a line sent by the client, without the line ending
+func readLine(r *bufio.Reader) string {
+	line, _ := r.ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+func dial(t *testing.T, addr string, timeout time.Duration) *Session {
+	s, err := Dial(addr, timeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// readReplies reads the client's answers to n option negotiations
+func readReplies(r *bufio.Reader, n int) string {
+	buf := make([]byte, 3*n)
+	io.ReadFull(r, buf)
+	return string(buf)
+}
+
+func TestLoginAndRun(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		// negotiation, then a banner with a ">" that is not a prompt
+		conn.Write([]byte(iac + will + optEcho + iac + will + optSuppressGoAhead +
+			iac + do + optTerminalType + "LTE modem > ready\r\nLogin: "))
+		readReplies(r, 3)
+		if readLine(r) != "admin" {
+			return
+		}
+		conn.Write([]byte("admin\r\nPassword: "))
+		if readLine(r) != "secret" {
+			return
+		}
+		conn.Write([]byte("\r\nRW-CLI> "))
+
+		for {
+			cmd := readLine(r)
+			switch cmd {
+			case "":
+				return
+			case "signal":
+				// subnegotiation and an escaped 255 byte in the middle of the output
+				conn.Write([]byte("signal\r\nRSRP -91\r\n" + iac + sb + optTerminalType + "\x01" + iac + se +
+					"SINR 30 " + iac + iac + "\r\nRW-CLI> "))
+			default:
+				conn.Write([]byte(cmd + "\r\nRW-CLI> "))
+			}
+		}
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.Login("admin", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Prompt() != "RW-CLI>" {
+		t.Errorf("expected prompt %q, got %q", "RW-CLI>", s.Prompt())
+	}
+
+	out, err := s.Run("signal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "RSRP -91\nSINR 30 \xff"; out != want {
+		t.Errorf("expected %q, got %q", want, out)
+	}
+
+	out, err = s.Run("quiet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "" {
+		t.Errorf("expected no output, got %q", out)
+	}
+}
+
+func TestRefusesOptions(t *testing.T) {
+	replies := make(chan string, 1)
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		// options that are already off need no answer, so only the WILL and DO
+		// are answered
+		conn.Write([]byte(iac + will + optEcho + iac + wont + optSuppressGoAhead +
+			iac + do + optTerminalType + iac + dont + optEcho + "# "))
+		replies <- readReplies(r, 2)
+		readLine(r)
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.Login("root", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := iac + dont + optEcho + iac + wont + optTerminalType
+	select {
+	case got := <-replies:
+		if got != want {
+			t.Errorf("expected replies %q, got %q", want, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no replies to the negotiation")
+	}
+}
+
+func TestWriteLineEscapesIAC(t *testing.T) {
+	lines := make(chan string, 1)
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		lines <- readLine(r)
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.WriteLine("pass\xffword")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := <-lines, "pass"+iac+iac+"word"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestCloseTwice(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		readLine(r)
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Close()
+	if err != nil {
+		t.Errorf("expected the second close to do nothing, got %v", err)
+	}
+}
+
+func TestLoginFailed(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		for {
+			conn.Write([]byte("Login: "))
+			readLine(r)
+			conn.Write([]byte("Password: "))
+			if readLine(r) == "" {
+				return
+			}
+			conn.Write([]byte("Login incorrect\r\n\r\n"))
+		}
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.Login("admin", "wrong")
+	if !errors.Is(err, ErrLoginFailed) {
+		t.Errorf("expected ErrLoginFailed, got %v", err)
+	}
+}
+
+func TestLoginTimeout(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		conn.Write([]byte("starting up"))
+		readLine(r)
+	})
+
+	s := dial(t, addr, 200*time.Millisecond)
+	err := s.Login("admin", "secret")
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		conn.Write([]byte("# "))
+		readLine(r)
+		conn.Write([]byte("hang\r\n"))
+		readLine(r)
+	})
+
+	s := dial(t, addr, 200*time.Millisecond)
+	err := s.Login("root", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Run("hang")
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected ErrTimeout, got %v", err)
+	}
+}
+
+func TestConnectionClosed(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		conn.Write([]byte("Login: "))
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.Login("admin", "secret")
+	if err == nil || errors.Is(err, ErrTimeout) || errors.Is(err, ErrLoginFailed) {
+		t.Errorf("expected an error for the closed connection, got %v", err)
+	}
+}
+
+func TestSetPrompt(t *testing.T) {
+	addr := startServer(t, func(conn net.Conn, r *bufio.Reader) {
+		conn.Write([]byte("root@RBR50:/# "))
+		cmd := readLine(r)
+		conn.Write([]byte(cmd + "\r\nORBI-PROMPT# "))
+		cmd = readLine(r)
+		conn.Write([]byte(cmd + "\r\nath0 ath1\r\nORBI-PROMPT# "))
+		readLine(r)
+	})
+
+	s := dial(t, addr, time.Second)
+	err := s.Login("root", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.SetPrompt(`PS1='ORBI''-PROMPT# '`, "ORBI-PROMPT# ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := s.Run("ls /sys/class/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "ath0 ath1" {
+		t.Errorf("expected %q, got %q", "ath0 ath1", out)
+	}
+}